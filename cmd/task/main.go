@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
 	"github.com/Citadelas/task/internal/app"
 	"github.com/Citadelas/task/internal/config"
+	"github.com/Citadelas/task/internal/lib/logger/handlers/slogtrace"
+	"github.com/Citadelas/task/internal/lib/logger/sl"
+	"github.com/Citadelas/task/internal/lib/tracing"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 const (
@@ -23,13 +28,53 @@ func main() {
 		slog.Any("cfg", cfg),
 		slog.Int("port", cfg.GRPC.Port),
 	)
-	application := app.New(log, cfg.GRPC.Port, cfg.StoragePath)
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		panic(err)
+	}
+	application := app.New(log, cfg)
+	if application.Certs != nil {
+		go application.Certs.Run()
+	}
 	go application.GRPCSrv.MustRun()
+	if application.HTTPSrv != nil {
+		go application.HTTPSrv.MustRun()
+	}
+	go application.Purger.Run()
+	if application.Events != nil {
+		go application.Events.Run()
+	}
+	if application.Metrics != nil {
+		go application.Metrics.MustRun()
+	}
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
 	<-stop
+	if application.HTTPSrv != nil {
+		application.HTTPSrv.Stop()
+	}
 	application.GRPCSrv.Stop()
+	application.Purger.Stop()
+	if application.Events != nil {
+		application.Events.Stop()
+	}
+	if application.Metrics != nil {
+		application.Metrics.Stop()
+	}
+	if application.Certs != nil {
+		application.Certs.Stop()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error("failed to flush traces", sl.Err(err))
+	}
 	log.Info("application stopped")
 }
 
@@ -38,15 +83,15 @@ func setupLogger(env string) *slog.Logger {
 	switch env {
 	case envLocal:
 		log = slog.New(
-			slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			slogtrace.NewTraceHandler(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})),
 		)
 	case envDev:
 		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			slogtrace.NewTraceHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})),
 		)
 	case envProd:
 		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
+			slogtrace.NewTraceHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo})),
 		)
 	}
 	return log
@@ -1,24 +1,126 @@
 package app
 
 import (
+	"crypto/tls"
+	"fmt"
+	eventsapp "github.com/Citadelas/task/internal/app/events"
 	grpcapp "github.com/Citadelas/task/internal/app/grpc"
+	httpapp "github.com/Citadelas/task/internal/app/http"
+	metricsapp "github.com/Citadelas/task/internal/app/metrics"
+	purgerapp "github.com/Citadelas/task/internal/app/purger"
+	"github.com/Citadelas/task/internal/config"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/lib/auth"
+	"github.com/Citadelas/task/internal/lib/certs"
+	"github.com/Citadelas/task/internal/lib/metrics"
 	"github.com/Citadelas/task/internal/services/task"
+	"github.com/Citadelas/task/internal/storage/memory"
 	"github.com/Citadelas/task/internal/storage/postgresql"
+	"github.com/Citadelas/task/internal/storage/sqlite"
 	"log/slog"
 )
 
 type App struct {
 	GRPCSrv *grpcapp.App
+	// HTTPSrv is nil when http.port is not configured.
+	HTTPSrv *httpapp.App
+	Purger  *purgerapp.App
+	// Metrics is nil when metrics.port is not configured.
+	Metrics *metricsapp.App
+	// Events relays task changes made by other instances; nil unless the
+	// storage is PostgreSQL.
+	Events *eventsapp.App
+	// Certs reloads the gRPC certificates; nil when TLS is off.
+	Certs *certs.Reloader
 }
 
-func New(log *slog.Logger, grpcPort int, storagePath string) *App {
-	storage, err := postgresql.New(storagePath)
+type taskStorage interface {
+	task.TaskGetter
+	task.TaskCreator
+	task.TaskUpdater
+	task.TaskDeleter
+	task.TaskLister
+	task.TaskTrash
+	task.TaskHistory
+	task.TaskTags
+	task.TaskHierarchy
+	task.TaskDependencies
+	task.TaskSeries
+	task.Transactor
+	metricsapp.TaskCounter
+	grpcapp.Pinger
+}
+
+func New(log *slog.Logger, cfg *config.Config) *App {
+	storage, err := newStorage(cfg.StorageDriver, cfg.StoragePath)
 	if err != nil {
 		panic(err)
 	}
-	taskService := task.New(log, storage, storage, storage, storage)
-	grpcApp := grpcapp.New(log, taskService, grpcPort)
+	workflow, err := models.NewWorkflow(cfg.Workflow.Transitions)
+	if err != nil {
+		panic(err)
+	}
+	taskService := task.New(log, storage, storage, storage, storage, storage, storage, storage, storage, storage,
+		storage, storage, storage, workflow)
+	var verifier *auth.Verifier
+	if cfg.Auth.Mode == config.AuthJWT {
+		verifier, err = auth.NewVerifier(auth.VerifierConfig{
+			HMACSecret:    string(cfg.Auth.HMACSecret),
+			PublicKeyFile: cfg.Auth.PublicKeyFile,
+			JWKSFile:      cfg.Auth.JWKSFile,
+			Issuer:        cfg.Auth.Issuer,
+			Audience:      cfg.Auth.Audience,
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+	var reloader *certs.Reloader
+	var tlsConfig *tls.Config
+	if cfg.GRPC.TLS.Enabled() {
+		reloader, err = certs.New(log, cfg.GRPC.TLS.CertFile, cfg.GRPC.TLS.KeyFile, cfg.GRPC.TLS.ClientCAFile,
+			cfg.GRPC.TLS.ReloadInterval)
+		if err != nil {
+			panic(err)
+		}
+		tlsConfig = reloader.TLSConfig()
+	}
+	grpcApp := grpcapp.New(log, taskService, storage, verifier, tlsConfig, cfg.GRPC.Port, cfg.Health.CheckInterval)
+	var httpApp *httpapp.App
+	if cfg.HTTP.Port != 0 {
+		httpApp = httpapp.New(log, taskService, verifier, cfg.HTTP.Port)
+	}
+	purgerApp := purgerapp.New(log, taskService, cfg.Trash.Retention, cfg.Trash.PurgeInterval)
+	var eventsApp *eventsapp.App
+	if pg, ok := storage.(*postgresql.Storage); ok {
+		eventsApp = eventsapp.New(log, pg, taskService)
+	}
+	var metricsApp *metricsapp.App
+	if cfg.Metrics.Port != 0 {
+		metrics.Registry.MustRegister(metricsapp.NewTasksCollector(log, storage))
+		if pg, ok := storage.(*postgresql.Storage); ok {
+			metrics.Registry.MustRegister(pg.PoolCollector())
+		}
+		metricsApp = metricsapp.New(log, cfg.Metrics.Port)
+	}
 	return &App{
 		GRPCSrv: grpcApp,
+		HTTPSrv: httpApp,
+		Purger:  purgerApp,
+		Metrics: metricsApp,
+		Events:  eventsApp,
+		Certs:   reloader,
+	}
+}
+
+func newStorage(driver, path string) (taskStorage, error) {
+	switch driver {
+	case config.StorageMemory:
+		return memory.New(), nil
+	case config.StoragePostgres:
+		return postgresql.New(path)
+	case config.StorageSQLite:
+		return sqlite.New(path)
 	}
+	return nil, fmt.Errorf("unknown storage driver %q", driver)
 }
@@ -0,0 +1,78 @@
+package eventsapp
+
+import (
+	"context"
+	"github.com/Citadelas/task/internal/lib/logger/sl"
+	"log/slog"
+	"time"
+)
+
+// retryDelay spaces reconnection attempts after the listener fails.
+const retryDelay = 5 * time.Second
+
+type EventListener interface {
+	ListenEvents(ctx context.Context, listening func(), notify func(uid uint64)) error
+}
+
+type Notifier interface {
+	Notify(uid uint64)
+	NotifyAll()
+}
+
+// App relays the task changes committed by other instances sharing the
+// database to the watchers of this one.
+type App struct {
+	log      *slog.Logger
+	listener EventListener
+	notifier Notifier
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func New(log *slog.Logger, listener EventListener, notifier Notifier) *App {
+	return &App{
+		log:      log,
+		listener: listener,
+		notifier: notifier,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Run listens until Stop is called, reconnecting after failures.
+func (a *App) Run() {
+	const op = "eventsapp.Run"
+	log := a.log.With(slog.String("op", op))
+	log.Info("starting task event listener")
+	defer close(a.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-a.stop
+		cancel()
+	}()
+	for {
+		// Changes committed while no listener was subscribed were never
+		// announced, so every watcher re-reads history once listening.
+		err := a.listener.ListenEvents(ctx, a.notifier.NotifyAll, a.notifier.Notify)
+		select {
+		case <-a.stop:
+			return
+		default:
+		}
+		log.Error("task event listener failed", sl.Err(err))
+		select {
+		case <-a.stop:
+			return
+		case <-time.After(retryDelay):
+		}
+	}
+}
+
+func (a *App) Stop() {
+	const op = "eventsapp.Stop"
+	a.log.With(slog.String("op", op)).Info("stopping task event listener")
+	close(a.stop)
+	<-a.done
+}
@@ -1,28 +1,75 @@
 package grpcapp
 
 import (
+	"crypto/tls"
 	"fmt"
 	taskgrpc "github.com/Citadelas/task/internal/grpc/task"
+	"github.com/Citadelas/task/internal/lib/auth"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 	"log/slog"
 	"net"
+	"time"
 )
 
 type App struct {
 	log        *slog.Logger
 	gRPCServer *grpc.Server
+	health     *healthChecker
 	port       int
+	tls        bool
 }
 
-func New(log *slog.Logger, taskService taskgrpc.Task, port int) *App {
-	gRPCServer := grpc.NewServer()
+// New builds the gRPC server. A nil verifier selects trusted mode, in which
+// calls are not authenticated and the user_id of each request is believed.
+// A nil tlsConfig serves plain TCP.
+func New(log *slog.Logger, taskService taskgrpc.Task, pinger Pinger, verifier *auth.Verifier,
+	tlsConfig *tls.Config, port int, healthInterval time.Duration) *App {
+	// The request id comes first so every later interceptor can see it,
+	// and recovery sits inside logging so a panic is still logged as
+	// Internal.
+	unary := []grpc.UnaryServerInterceptor{
+		unaryRequestId(),
+		unaryLogging(log),
+		unaryMetrics(),
+		unaryRecovery(log),
+	}
+	stream := []grpc.StreamServerInterceptor{
+		streamRequestId(),
+		streamLogging(log),
+		streamMetrics(),
+		streamRecovery(log),
+	}
+	if verifier != nil {
+		unary = append(unary, unaryAuth(log, verifier))
+		stream = append(stream, streamAuth(log, verifier))
+	}
+	unary = append(unary, taskgrpc.UnaryErrorInterceptor(log))
+	stream = append(stream, taskgrpc.StreamErrorInterceptor(log))
+	opts := []grpc.ServerOption{
+		// The stats handler picks up the W3C trace context from incoming
+		// metadata and opens a server span before any interceptor runs.
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	gRPCServer := grpc.NewServer(opts...)
+	health := newHealthChecker(log, pinger, healthInterval)
 	taskgrpc.Register(gRPCServer, taskService)
+	healthpb.RegisterHealthServer(gRPCServer, health.server)
 	reflection.Register(gRPCServer)
 	return &App{
 		log:        log,
 		gRPCServer: gRPCServer,
+		health:     health,
 		port:       port,
+		tls:        tlsConfig != nil,
 	}
 }
 
@@ -42,7 +89,8 @@ func (a *App) Run() error {
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
-	log.Info("grpc server is running", slog.String("addr", l.Addr().String()))
+	log.Info("grpc server is running", slog.String("addr", l.Addr().String()), slog.Bool("tls", a.tls))
+	go a.health.run()
 	if err := a.gRPCServer.Serve(l); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -52,5 +100,6 @@ func (a *App) Run() error {
 func (a *App) Stop() {
 	const op = "grpcapp.stop"
 	a.log.With(slog.String("op", op)).Info("stopping gRPC server", slog.Int("port", a.port))
+	a.health.shutdown()
 	a.gRPCServer.GracefulStop()
 }
@@ -0,0 +1,85 @@
+package grpcapp
+
+import (
+	"context"
+	"github.com/Citadelas/task/internal/lib/auth"
+	"github.com/Citadelas/task/internal/lib/logger/sl"
+	"github.com/Citadelas/task/internal/lib/requestid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"strings"
+)
+
+const authorizationHeader = "authorization"
+
+// errInvalidToken is all a caller learns about a rejected token; why it
+// was rejected is logged instead.
+var errInvalidToken = status.Error(codes.Unauthenticated, "invalid token")
+
+// Health probes and reflection stay open so orchestrators and tooling
+// work without credentials.
+var publicMethodPrefixes = []string{healthMethodPrefix, "/grpc.reflection."}
+
+func isPublic(method string) bool {
+	for _, prefix := range publicMethodPrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticate verifies the bearer token of a call and returns a context
+// carrying its user.
+func authenticate(ctx context.Context, log *slog.Logger, verifier *auth.Verifier,
+	method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(authorizationHeader)
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	scheme, token, ok := strings.Cut(values[0], " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") {
+		return nil, status.Error(codes.Unauthenticated, "authorization must be a bearer token")
+	}
+	uid, err := verifier.Verify(strings.TrimSpace(token))
+	if err != nil {
+		log.WarnContext(ctx, "rejected bearer token",
+			slog.String("method", method),
+			slog.String("request_id", requestid.FromContext(ctx)),
+			sl.Err(err),
+		)
+		return nil, errInvalidToken
+	}
+	return auth.NewContext(ctx, uid), nil
+}
+
+func unaryAuth(log *slog.Logger, verifier *auth.Verifier) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (any, error) {
+		if isPublic(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		ctx, err := authenticate(ctx, log, verifier, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamAuth(log *slog.Logger, verifier *auth.Verifier) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if isPublic(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		ctx, err := authenticate(ss.Context(), log, verifier, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
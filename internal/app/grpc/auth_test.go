@@ -0,0 +1,77 @@
+package grpcapp
+
+import (
+	"context"
+	taskv1 "github.com/Citadelas/protos/golang/task"
+	"github.com/Citadelas/task/internal/lib/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func bearer(t *testing.T, secret, subject string) context.Context {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   subject,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return metadata.AppendToOutgoingContext(context.Background(), authorizationHeader, "Bearer "+token)
+}
+
+func TestAuthUsesTokenUser(t *testing.T) {
+	verifier, err := auth.NewVerifier(auth.VerifierConfig{HMACSecret: testSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := New(slog.New(slog.DiscardHandler), getOnly{}, okPinger{}, verifier, nil, 0, time.Hour)
+	conn := dial(t, app)
+	client := taskv1.NewTaskServiceClient(conn)
+
+	res, err := client.GetTask(bearer(t, testSecret, "42"), &taskv1.GetTaskRequest{Id: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.GetTask().GetUserId(); got != 42 {
+		t.Fatalf("task of user %d, want the token's user 42", got)
+	}
+	// A user id in the request cannot make the call act for someone else.
+	res, err = client.GetTask(bearer(t, testSecret, "42"), &taskv1.GetTaskRequest{Id: 1, UserId: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.GetTask().GetUserId(); got != 42 {
+		t.Fatalf("task of user %d for a request naming user 7, want the token's user 42", got)
+	}
+
+	rejected := map[string]context.Context{
+		"missing token": context.Background(),
+		"wrong secret":  bearer(t, "fedcba9876543210fedcba9876543210", "42"),
+		"bad subject":   bearer(t, testSecret, "alice"),
+	}
+	for name, ctx := range rejected {
+		_, err := client.GetTask(ctx, &taskv1.GetTaskRequest{Id: 1, UserId: 42})
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("%s: err = %v, want Unauthenticated", name, err)
+		}
+		// Why a token was rejected is logged, not returned.
+		if name != "missing token" && status.Convert(err).Message() != "invalid token" {
+			t.Errorf("%s: message = %q, want %q", name, status.Convert(err).Message(), "invalid token")
+		}
+	}
+
+	// Health probes need no token.
+	health := healthpb.NewHealthClient(conn)
+	if _, err := health.Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("health check without a token: %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package grpcapp
+
+import (
+	"context"
+	"github.com/Citadelas/task/internal/lib/logger/sl"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// The overall service ("") reports liveness: it serves as long as the
+// process does. readinessService, named after the task service, reports
+// whether requests can be handled, i.e. whether storage answers pings.
+const (
+	livenessService  = ""
+	readinessService = "task.TaskService"
+)
+
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// healthChecker pings storage every interval and flips readiness
+// accordingly. Readiness starts as NOT_SERVING until the first ping
+// succeeds.
+type healthChecker struct {
+	log      *slog.Logger
+	server   *health.Server
+	pinger   Pinger
+	interval time.Duration
+	started  atomic.Bool
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func newHealthChecker(log *slog.Logger, pinger Pinger, interval time.Duration) *healthChecker {
+	server := health.NewServer()
+	server.SetServingStatus(livenessService, healthpb.HealthCheckResponse_SERVING)
+	server.SetServingStatus(readinessService, healthpb.HealthCheckResponse_NOT_SERVING)
+	return &healthChecker{
+		log:      log,
+		server:   server,
+		pinger:   pinger,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (h *healthChecker) run() {
+	const op = "grpcapp.healthChecker.run"
+	log := h.log.With(slog.String("op", op))
+	h.started.Store(true)
+	defer close(h.done)
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	// Only changes in readiness are logged, so a lasting outage does not
+	// flood the log.
+	ready, first := false, true
+	for {
+		err := h.ping()
+		if err != nil && (ready || first) {
+			log.Error("storage is unreachable, not ready", sl.Err(err))
+		} else if err == nil && !ready {
+			log.Info("storage is reachable, ready")
+		}
+		ready, first = err == nil, false
+		status := healthpb.HealthCheckResponse_NOT_SERVING
+		if ready {
+			status = healthpb.HealthCheckResponse_SERVING
+		}
+		h.server.SetServingStatus(readinessService, status)
+		select {
+		case <-h.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *healthChecker) ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.interval)
+	defer cancel()
+	return h.pinger.Ping(ctx)
+}
+
+// shutdown stops the checks and reports every service as NOT_SERVING, so
+// clients move away while in-flight calls drain.
+func (h *healthChecker) shutdown() {
+	h.server.Shutdown()
+	close(h.stop)
+	if h.started.Load() {
+		<-h.done
+	}
+}
@@ -0,0 +1,69 @@
+package grpcapp
+
+import (
+	"context"
+	"errors"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type okPinger struct{}
+
+func (okPinger) Ping(context.Context) error {
+	return nil
+}
+
+// flakyPinger fails while down is set.
+type flakyPinger struct {
+	down atomic.Bool
+}
+
+func (p *flakyPinger) Ping(context.Context) error {
+	if p.down.Load() {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func TestReadinessFollowsStorage(t *testing.T) {
+	pinger := &flakyPinger{}
+	app := New(slog.New(slog.DiscardHandler), getOnly{}, pinger, nil, nil, 0, 10*time.Millisecond)
+	client := healthpb.NewHealthClient(dial(t, app))
+
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		t.Helper()
+		res, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.GetStatus()
+	}
+	await := func(want healthpb.HealthCheckResponse_ServingStatus) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for check(readinessService) != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("readiness did not become %v", want)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		if got := check(livenessService); got != healthpb.HealthCheckResponse_SERVING {
+			t.Fatalf("liveness = %v while readiness is %v", got, want)
+		}
+	}
+
+	// Readiness waits for the first successful ping.
+	if got := check(readinessService); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("readiness before the first ping = %v", got)
+	}
+	go app.health.run()
+	t.Cleanup(app.health.shutdown)
+	await(healthpb.HealthCheckResponse_SERVING)
+	pinger.down.Store(true)
+	await(healthpb.HealthCheckResponse_NOT_SERVING)
+	pinger.down.Store(false)
+	await(healthpb.HealthCheckResponse_SERVING)
+}
@@ -0,0 +1,156 @@
+package grpcapp
+
+import (
+	"context"
+	"github.com/Citadelas/task/internal/lib/metrics"
+	"github.com/Citadelas/task/internal/lib/requestid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// maxRequestIdLen bounds client-supplied request ids so they cannot bloat
+// every log line of a call.
+const maxRequestIdLen = 128
+
+const healthMethodPrefix = "/grpc.health.v1.Health/"
+
+// requestIdFromMetadata returns the caller's request id, or a new one if
+// it sent none or an unusable one.
+func requestIdFromMetadata(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestid.Header); len(values) > 0 {
+			id := strings.TrimSpace(values[0])
+			if id != "" && len(id) <= maxRequestIdLen {
+				return id
+			}
+		}
+	}
+	return requestid.New()
+}
+
+// The request id is echoed in both the header and the trailer: a failed
+// call may end without sending headers, but the trailer always arrives.
+func unaryRequestId() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (any, error) {
+		id := requestIdFromMetadata(ctx)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestid.Header, id))
+		_ = grpc.SetTrailer(ctx, metadata.Pairs(requestid.Header, id))
+		return handler(requestid.NewContext(ctx, id), req)
+	}
+}
+
+func streamRequestId() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		id := requestIdFromMetadata(ss.Context())
+		_ = ss.SetHeader(metadata.Pairs(requestid.Header, id))
+		ss.SetTrailer(metadata.Pairs(requestid.Header, id))
+		return handler(srv, &contextStream{ServerStream: ss, ctx: requestid.NewContext(ss.Context(), id)})
+	}
+}
+
+// contextStream lets stream interceptors hand a derived context down.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func unaryLogging(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logRequest(ctx, log, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func streamLogging(log *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logRequest(ss.Context(), log, info.FullMethod, start, err)
+		return err
+	}
+}
+
+func logRequest(ctx context.Context, log *slog.Logger, method string, start time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch {
+	case code == codes.Internal, code == codes.Unknown, code == codes.DataLoss:
+		level = slog.LevelError
+	case strings.HasPrefix(method, healthMethodPrefix):
+		// Probes arrive every few seconds and would drown other calls.
+		level = slog.LevelDebug
+	}
+	log.LogAttrs(ctx, level, "grpc request",
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("latency", time.Since(start)),
+		slog.String("request_id", requestid.FromContext(ctx)),
+	)
+}
+
+func unaryMetrics() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		metrics.ObserveGRPC(info.FullMethod, status.Code(err).String(), time.Since(start))
+		return resp, err
+	}
+}
+
+func streamMetrics() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		metrics.ObserveGRPC(info.FullMethod, status.Code(err).String(), time.Since(start))
+		return err
+	}
+}
+
+func unaryRecovery(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = recovered(ctx, log, info.FullMethod, p)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+func streamRecovery(log *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = recovered(ss.Context(), log, info.FullMethod, p)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+func recovered(ctx context.Context, log *slog.Logger, method string, p any) error {
+	id := requestid.FromContext(ctx)
+	log.ErrorContext(ctx, "panic in grpc handler",
+		slog.String("method", method),
+		slog.String("request_id", id),
+		slog.Any("panic", p),
+		slog.String("stack", string(debug.Stack())),
+	)
+	return status.Errorf(codes.Internal, "internal error (correlation id %s)", id)
+}
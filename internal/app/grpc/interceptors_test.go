@@ -0,0 +1,119 @@
+package grpcapp
+
+import (
+	"context"
+	taskv1 "github.com/Citadelas/protos/golang/task"
+	"github.com/Citadelas/task/internal/domain/models"
+	taskgrpc "github.com/Citadelas/task/internal/grpc/task"
+	"github.com/Citadelas/task/internal/lib/requestid"
+	taskservice "github.com/Citadelas/task/internal/services/task"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dial serves app on a random local port and returns a client connection
+// to it.
+func dial(t *testing.T, app *App) *grpc.ClientConn {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = app.gRPCServer.Serve(l) }()
+	t.Cleanup(app.gRPCServer.Stop)
+
+	conn, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// getOnly serves GetTask for task 1 only; other calls are not used.
+type getOnly struct {
+	taskgrpc.Task
+}
+
+func (getOnly) GetTask(_ context.Context, id, uid uint64) (*models.Task, error) {
+	if id != 1 {
+		return nil, taskservice.ErrWrongId
+	}
+	return &models.Task{Id: id, UserId: uid, Title: "title", Priority: models.PriorityLow, Status: models.StatusTodo,
+		Version: 1}, nil
+}
+
+func (getOnly) NextStatuses(models.Status) []models.Status {
+	return nil
+}
+
+// panicking panics in GetTask.
+type panicking struct {
+	taskgrpc.Task
+}
+
+func (panicking) GetTask(context.Context, uint64, uint64) (*models.Task, error) {
+	panic("boom")
+}
+
+func TestPanicIsRecovered(t *testing.T) {
+	app := New(slog.New(slog.DiscardHandler), panicking{}, okPinger{}, nil, nil, 0, time.Hour)
+	client := taskv1.NewTaskServiceClient(dial(t, app))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), requestid.Header, "req-7")
+
+	var trailer metadata.MD
+	_, err := client.GetTask(ctx, &taskv1.GetTaskRequest{Id: 1, UserId: 2}, grpc.Trailer(&trailer))
+	st := status.Convert(err)
+	if st.Code() != codes.Internal {
+		t.Fatalf("GetTask = %v, want Internal", err)
+	}
+	if strings.Contains(st.Message(), "boom") || !strings.Contains(st.Message(), "req-7") {
+		t.Fatalf("message = %q, want only the correlation id", st.Message())
+	}
+	if got := trailer.Get(requestid.Header); len(got) != 1 || got[0] != "req-7" {
+		t.Fatalf("request id trailer = %v, want [req-7]", got)
+	}
+
+	// The server survived the panic.
+	if _, err := client.GetTask(ctx, &taskv1.GetTaskRequest{Id: 1, UserId: 2}); status.Code(err) != codes.Internal {
+		t.Fatalf("second GetTask = %v, want Internal", err)
+	}
+}
+
+func TestRequestIdIsGeneratedAndEchoed(t *testing.T) {
+	app := New(slog.New(slog.DiscardHandler), getOnly{}, okPinger{}, nil, nil, 0, time.Hour)
+	client := taskv1.NewTaskServiceClient(dial(t, app))
+
+	var header, trailer metadata.MD
+	_, err := client.GetTask(context.Background(), &taskv1.GetTaskRequest{Id: 1, UserId: 2}, grpc.Header(&header),
+		grpc.Trailer(&trailer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := header.Get(requestid.Header)
+	if len(id) != 1 || id[0] == "" {
+		t.Fatalf("request id header = %v", id)
+	}
+	if got := trailer.Get(requestid.Header); len(got) != 1 || got[0] != id[0] {
+		t.Fatalf("request id trailer = %v, want %v", got, id)
+	}
+
+	// Oversized ids are replaced rather than logged.
+	ctx := metadata.AppendToOutgoingContext(context.Background(), requestid.Header,
+		strings.Repeat("x", maxRequestIdLen+1))
+	_, err = client.GetTask(ctx, &taskv1.GetTaskRequest{Id: 1, UserId: 2}, grpc.Header(&header))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get(requestid.Header); len(got) != 1 || len(got[0]) > maxRequestIdLen {
+		t.Fatalf("request id header for an oversized id = %v", got)
+	}
+}
@@ -0,0 +1,80 @@
+package grpcapp
+
+import (
+	"context"
+	taskv1 "github.com/Citadelas/protos/golang/task"
+	"github.com/Citadelas/task/internal/lib/metrics"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scrape returns the value of every sample the metrics handler exposes,
+// keyed by series.
+func scrape(t *testing.T) map[string]float64 {
+	t.Helper()
+	srv := httptest.NewServer(metrics.Handler())
+	defer srv.Close()
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := make(map[string]float64)
+	for _, line := range strings.Split(string(body), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		series, value, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			t.Fatalf("sample %q: %v", line, err)
+		}
+		samples[series] = v
+	}
+	return samples
+}
+
+func TestUnaryCallsAreMeasured(t *testing.T) {
+	app := New(slog.New(slog.DiscardHandler), getOnly{}, okPinger{}, nil, nil, 0, time.Hour)
+	client := taskv1.NewTaskServiceClient(dial(t, app))
+	// Other tests of the package call GetTask as well, so only the change
+	// counts.
+	before := scrape(t)
+	ctx := context.Background()
+	if _, err := client.GetTask(ctx, &taskv1.GetTaskRequest{Id: 1, UserId: 2}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := client.GetTask(ctx, &taskv1.GetTaskRequest{Id: 3, UserId: 2})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("GetTask(3) = %v, want NotFound", err)
+	}
+
+	after := scrape(t)
+	method := taskv1.TaskService_GetTask_FullMethodName
+	for _, series := range []string{
+		`task_grpc_requests_total{code="OK",method="` + method + `"}`,
+		`task_grpc_requests_total{code="NotFound",method="` + method + `"}`,
+		`task_grpc_request_duration_seconds_count{code="OK",method="` + method + `"}`,
+		`task_grpc_request_duration_seconds_count{code="NotFound",method="` + method + `"}`,
+		`task_grpc_request_duration_seconds_bucket{code="OK",method="` + method + `",le="+Inf"}`,
+	} {
+		if got := after[series] - before[series]; got != 1 {
+			t.Errorf("%s grew by %v, want 1", series, got)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package httpapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	taskhttp "github.com/Citadelas/task/internal/http/task"
+	"github.com/Citadelas/task/internal/lib/auth"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// App serves the REST/JSON gateway to the task service.
+type App struct {
+	log    *slog.Logger
+	server *http.Server
+	port   int
+}
+
+// New builds the gateway. A nil verifier selects trusted mode, as for
+// grpcapp.New.
+func New(log *slog.Logger, taskService taskhttp.Task, verifier *auth.Verifier, port int) *App {
+	shutdown := make(chan struct{})
+	mux := http.NewServeMux()
+	taskhttp.Register(mux, log, taskService, shutdown)
+	var handler http.Handler = mux
+	if verifier != nil {
+		handler = authenticate(log, verifier, handler)
+	}
+	// Same order as the gRPC interceptors: request id first, recovery
+	// inside logging.
+	handler = requestId(tracing(logging(log, recovery(log, handler))))
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	// Shutdown waits for requests to finish, which event streams never do
+	// on their own.
+	server.RegisterOnShutdown(func() { close(shutdown) })
+	return &App{
+		log:    log,
+		server: server,
+		port:   port,
+	}
+}
+
+func (a *App) MustRun() {
+	if err := a.Run(); err != nil {
+		panic(err)
+	}
+}
+
+func (a *App) Run() error {
+	const op = "httpapp.Run"
+	log := a.log.With(slog.String("op", op),
+		slog.Int("port", a.port),
+	)
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", a.port))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("http gateway is running", slog.String("addr", l.Addr().String()))
+	if err := a.server.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (a *App) Stop() {
+	const op = "httpapp.Stop"
+	a.log.With(slog.String("op", op)).Info("stopping http gateway", slog.Int("port", a.port))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = a.server.Shutdown(ctx)
+}
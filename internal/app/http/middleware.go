@@ -0,0 +1,132 @@
+package httpapp
+
+import (
+	"fmt"
+	taskhttp "github.com/Citadelas/task/internal/http/task"
+	"github.com/Citadelas/task/internal/lib/auth"
+	"github.com/Citadelas/task/internal/lib/logger/sl"
+	"github.com/Citadelas/task/internal/lib/requestid"
+	tracinglib "github.com/Citadelas/task/internal/lib/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// maxRequestIdLen bounds client-supplied request ids so they cannot bloat
+// every log line of a call.
+const maxRequestIdLen = 128
+
+// statusRecorder remembers the status code written for logging.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the flusher of event streams.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func requestId(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSpace(r.Header.Get(requestid.Header))
+		if id == "" || len(id) > maxRequestIdLen {
+			id = requestid.New()
+		}
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+	})
+}
+
+// tracing continues a trace from the W3C traceparent header, like the
+// gRPC stats handler does for metadata.
+func tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracinglib.Start(ctx, "HTTP "+r.Method,
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+		)
+		defer span.End()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func logging(log *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		level := slog.LevelInfo
+		if rec.code >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		log.LogAttrs(r.Context(), level, "http request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.code),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("request_id", requestid.FromContext(r.Context())),
+		)
+	})
+}
+
+func recovery(log *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if p := recover(); p != nil {
+				id := requestid.FromContext(r.Context())
+				log.ErrorContext(r.Context(), "panic in http handler",
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.String("request_id", id),
+					slog.Any("panic", p),
+					slog.String("stack", string(debug.Stack())),
+				)
+				taskhttp.WriteStatus(w, status.New(codes.Internal, fmt.Sprintf("internal error (correlation id %s)", id)))
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticate verifies the bearer token of a request, as the gRPC auth
+// interceptor does, and hands its user down in the context.
+func authenticate(log *slog.Logger, verifier *auth.Verifier, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "bearer") {
+			unauthenticated(w, "missing bearer token")
+			return
+		}
+		uid, err := verifier.Verify(strings.TrimSpace(token))
+		if err != nil {
+			log.WarnContext(r.Context(), "rejected bearer token",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("request_id", requestid.FromContext(r.Context())),
+				sl.Err(err),
+			)
+			unauthenticated(w, "invalid token")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), uid)))
+	})
+}
+
+func unauthenticated(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	taskhttp.WriteStatus(w, status.New(codes.Unauthenticated, message))
+}
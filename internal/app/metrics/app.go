@@ -0,0 +1,62 @@
+package metricsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Citadelas/task/internal/lib/metrics"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// App serves the Prometheus metrics endpoint on its own HTTP listener.
+type App struct {
+	log    *slog.Logger
+	server *http.Server
+	port   int
+}
+
+func New(log *slog.Logger, port int) *App {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	return &App{
+		log: log,
+		server: &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+		port: port,
+	}
+}
+
+func (a *App) MustRun() {
+	if err := a.Run(); err != nil {
+		panic(err)
+	}
+}
+
+func (a *App) Run() error {
+	const op = "metricsapp.Run"
+	log := a.log.With(slog.String("op", op),
+		slog.Int("port", a.port),
+	)
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", a.port))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("metrics server is running", slog.String("addr", l.Addr().String()))
+	if err := a.server.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (a *App) Stop() {
+	const op = "metricsapp.Stop"
+	a.log.With(slog.String("op", op)).Info("stopping metrics server", slog.Int("port", a.port))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = a.server.Shutdown(ctx)
+}
@@ -0,0 +1,53 @@
+package metricsapp
+
+import (
+	"context"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/lib/logger/sl"
+	"github.com/prometheus/client_golang/prometheus"
+	"log/slog"
+	"time"
+)
+
+type TaskCounter interface {
+	CountTasksByStatus(ctx context.Context) (map[models.Status]int64, error)
+}
+
+var tasksDesc = prometheus.NewDesc("task_tasks", "Tasks outside the trash, by status.", []string{"status"}, nil)
+
+// countTimeout bounds the query made on each scrape.
+const countTimeout = 5 * time.Second
+
+// TasksCollector reports how many tasks are in each status, counted from
+// storage whenever the endpoint is scraped.
+type TasksCollector struct {
+	log     *slog.Logger
+	counter TaskCounter
+}
+
+func NewTasksCollector(log *slog.Logger, counter TaskCounter) *TasksCollector {
+	return &TasksCollector{log: log, counter: counter}
+}
+
+func (c *TasksCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- tasksDesc
+}
+
+func (c *TasksCollector) Collect(ch chan<- prometheus.Metric) {
+	const op = "metricsapp.TasksCollector.Collect"
+	ctx, cancel := context.WithTimeout(context.Background(), countTimeout)
+	defer cancel()
+	counts, err := c.counter.CountTasksByStatus(ctx)
+	if err != nil {
+		c.log.With(slog.String("op", op)).Error("failed to count tasks", sl.Err(err))
+		return
+	}
+	// Every status is reported, even at zero, so series do not vanish.
+	for _, status := range append([]models.Status{models.StatusUnspecified}, models.Statuses...) {
+		label := status.String()
+		if status == models.StatusUnspecified {
+			label = "UNSPECIFIED"
+		}
+		ch <- prometheus.MustNewConstMetric(tasksDesc, prometheus.GaugeValue, float64(counts[status]), label)
+	}
+}
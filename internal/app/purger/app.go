@@ -0,0 +1,76 @@
+package purgerapp
+
+import (
+	"context"
+	"github.com/Citadelas/task/internal/lib/logger/sl"
+	"log/slog"
+	"time"
+)
+
+type TrashPurger interface {
+	PurgeTrash(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+// App periodically removes tasks that have been in the trash for longer
+// than the retention period.
+type App struct {
+	log       *slog.Logger
+	purger    TrashPurger
+	retention time.Duration
+	interval  time.Duration
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func New(log *slog.Logger, purger TrashPurger, retention, interval time.Duration) *App {
+	return &App{
+		log:       log,
+		purger:    purger,
+		retention: retention,
+		interval:  interval,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Run purges once immediately and then every interval until Stop is called.
+func (a *App) Run() {
+	const op = "purgerapp.Run"
+	log := a.log.With(slog.String("op", op))
+	log.Info("starting trash purger",
+		slog.Duration("retention", a.retention),
+		slog.Duration("interval", a.interval),
+	)
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for {
+		a.purge(log)
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *App) purge(log *slog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.interval)
+	defer cancel()
+	purged, err := a.purger.PurgeTrash(ctx, a.retention)
+	if err != nil {
+		log.Error("failed to purge trash", sl.Err(err))
+		return
+	}
+	if purged > 0 {
+		log.Info("purged trashed tasks", slog.Int64("count", purged))
+	}
+}
+
+func (a *App) Stop() {
+	const op = "purgerapp.Stop"
+	a.log.With(slog.String("op", op)).Info("stopping trash purger")
+	close(a.stop)
+	<-a.done
+}
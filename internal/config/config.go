@@ -1,21 +1,130 @@
 package config
 
 import (
+	"encoding/json"
 	"flag"
 	"github.com/ilyakaznacheev/cleanenv"
+	"log/slog"
 	"os"
 	"time"
 )
 
+const envProd = "prod"
+
+const (
+	AuthJWT     = "jwt"
+	AuthTrusted = "trusted"
+)
+
+const (
+	StoragePostgres = "postgres"
+	StorageSQLite   = "sqlite"
+	StorageMemory   = "memory"
+)
+
 type Config struct {
-	Env         string     `yaml:"env" env-default:"local"`
-	StoragePath string     `yaml:"storage_path" env-required:"true"`
-	GRPC        GRPCConfig `yaml:"grpc"`
+	Env           string         `yaml:"env" env-default:"local"`
+	StorageDriver string         `yaml:"storage_driver" env-default:"postgres"`
+	StoragePath   string         `yaml:"storage_path"`
+	GRPC          GRPCConfig     `yaml:"grpc"`
+	HTTP          HTTPConfig     `yaml:"http"`
+	Trash         TrashConfig    `yaml:"trash"`
+	Workflow      WorkflowConfig `yaml:"workflow"`
+	Metrics       MetricsConfig  `yaml:"metrics"`
+	Tracing       TracingConfig  `yaml:"tracing"`
+	Health        HealthConfig   `yaml:"health"`
+	Auth          AuthConfig     `yaml:"auth"`
 }
 
 type GRPCConfig struct {
 	Port    int           `yaml:"port"`
 	Timeout time.Duration `yaml:"timeout"`
+	TLS     TLSConfig     `yaml:"tls"`
+}
+
+// TLSConfig enables TLS on the gRPC listener when CertFile and KeyFile are
+// set. With ClientCAFile set, clients must also present a certificate signed
+// by one of its CAs. The files are re-read when they change.
+type TLSConfig struct {
+	CertFile       string        `yaml:"cert_file"`
+	KeyFile        string        `yaml:"key_file"`
+	ClientCAFile   string        `yaml:"client_ca_file"`
+	ReloadInterval time.Duration `yaml:"reload_interval" env-default:"10s"`
+}
+
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// HTTPConfig sets the port of the REST/JSON gateway; zero disables it.
+type HTTPConfig struct {
+	Port int `yaml:"port"`
+}
+
+type TrashConfig struct {
+	Retention     time.Duration `yaml:"retention" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// WorkflowConfig maps each status to the statuses a task may move to from
+// it. An empty table selects models.DefaultTransitions.
+type WorkflowConfig struct {
+	Transitions map[string][]string `yaml:"transitions"`
+}
+
+// MetricsConfig sets the port of the Prometheus metrics listener; zero
+// disables it.
+type MetricsConfig struct {
+	Port int `yaml:"port"`
+}
+
+// TracingConfig selects where OpenTelemetry spans go: "none", "stdout" or
+// "otlp" (gRPC to Endpoint, e.g. a collector at localhost:4317).
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env-default:"none"`
+	Endpoint    string  `yaml:"endpoint" env-default:"localhost:4317"`
+	Insecure    bool    `yaml:"insecure"`
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
+}
+
+// HealthConfig sets how often readiness is re-checked by pinging storage.
+type HealthConfig struct {
+	CheckInterval time.Duration `yaml:"check_interval" env-default:"5s"`
+}
+
+// AuthConfig selects how callers are identified. In "jwt" mode every call
+// needs a bearer token verified with one of the configured keys; in
+// "trusted" mode, meant for calls between internal services, the user_id
+// of each request is taken as is.
+type AuthConfig struct {
+	Mode          string `yaml:"mode" env-default:"jwt"`
+	HMACSecret    Secret `yaml:"hmac_secret" env:"AUTH_HMAC_SECRET"`
+	PublicKeyFile string `yaml:"public_key_file"`
+	JWKSFile      string `yaml:"jwks_file"`
+	Issuer        string `yaml:"issuer"`
+	Audience      string `yaml:"audience"`
+}
+
+// Secret is a config value kept out of logs. It prints as [REDACTED]
+// whether formatted, logged as a value or marshaled to JSON as part of the
+// config, as slog's JSON handler does.
+type Secret string
+
+const redacted = "[REDACTED]"
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
 }
 
 func MustLoad() *Config {
@@ -30,6 +139,44 @@ func MustLoad() *Config {
 	if err := cleanenv.ReadConfig(path, &cfg); err != nil {
 		panic("failed to read config " + err.Error())
 	}
+	switch cfg.StorageDriver {
+	case StoragePostgres, StorageSQLite:
+		if cfg.StoragePath == "" {
+			panic("storage_path is required for the " + cfg.StorageDriver + " storage driver")
+		}
+	case StorageMemory:
+		// Everything the memory storage holds is lost on restart, and every
+		// transaction copies all of it.
+		if cfg.Env == envProd {
+			panic("the memory storage driver is for local and dev environments only")
+		}
+	default:
+		panic("unknown storage driver " + cfg.StorageDriver)
+	}
+	switch cfg.Auth.Mode {
+	case AuthJWT:
+		if cfg.Auth.HMACSecret == "" && cfg.Auth.PublicKeyFile == "" && cfg.Auth.JWKSFile == "" {
+			panic("auth.mode jwt needs auth.hmac_secret, auth.public_key_file or auth.jwks_file")
+		}
+	case AuthTrusted:
+	default:
+		panic("unknown auth mode " + cfg.Auth.Mode)
+	}
+	if (cfg.GRPC.TLS.CertFile == "") != (cfg.GRPC.TLS.KeyFile == "") {
+		panic("grpc.tls.cert_file and grpc.tls.key_file must be set together")
+	}
+	if cfg.GRPC.TLS.ClientCAFile != "" && !cfg.GRPC.TLS.Enabled() {
+		panic("grpc.tls.client_ca_file needs grpc.tls.cert_file and grpc.tls.key_file")
+	}
+	if cfg.GRPC.TLS.Enabled() && cfg.GRPC.TLS.ReloadInterval <= 0 {
+		panic("grpc.tls.reload_interval must be positive")
+	}
+	if cfg.Health.CheckInterval <= 0 {
+		panic("health.check_interval must be positive")
+	}
+	if cfg.Trash.PurgeInterval <= 0 {
+		panic("trash.purge_interval must be positive")
+	}
 	return &cfg
 }
 
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSecretRedacted(t *testing.T) {
+	const secret = "supersecret"
+	cfg := &Config{Auth: AuthConfig{Mode: AuthJWT, HMACSecret: secret}}
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+	log.Info("starting", slog.Any("cfg", cfg), slog.Any("secret", cfg.Auth.HMACSecret))
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("starting", slog.Any("cfg", cfg))
+	fmt.Fprintf(&buf, "%v %+v %s", cfg, *cfg, cfg.Auth.HMACSecret)
+
+	out := buf.String()
+	if strings.Contains(out, secret) {
+		t.Fatalf("secret leaked into logs: %s", out)
+	}
+	if !strings.Contains(out, `"HMACSecret":"[REDACTED]"`) {
+		t.Errorf("JSON log lacks the redacted secret: %s", out)
+	}
+}
+
+func TestSecretEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("starting", slog.Any("cfg", &Config{}))
+	if !strings.Contains(buf.String(), `"HMACSecret":""`) {
+		t.Errorf("unset secret should log as empty: %s", buf.String())
+	}
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// TaskEvent reports one change to a user's tasks. It is built from the
+// history entry recording the change: Sequence is that entry's id, so
+// events are ordered and a watcher can resume after the last one it saw,
+// and Type is its action. Task holds the task's current state, which may
+// already include later changes, and is nil once the task is deleted or
+// purged.
+type TaskEvent struct {
+	Sequence  uint64
+	Type      string
+	TaskId    uint64
+	UserId    uint64
+	ActorId   uint64
+	Changes   FieldChanges
+	Task      *Task
+	CreatedAt time.Time
+}
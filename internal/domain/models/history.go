@@ -0,0 +1,147 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ActionCreated       = "created"
+	ActionUpdated       = "updated"
+	ActionStatusChanged = "status_changed"
+	ActionDeleted       = "deleted"
+	ActionRestored      = "restored"
+	ActionPurged        = "purged"
+)
+
+// HistoryEntry is an immutable record of one change made to a task.
+type HistoryEntry struct {
+	Id        uint64
+	TaskId    uint64
+	UserId    uint64
+	ActorId   uint64
+	Action    string
+	Changes   FieldChanges
+	CreatedAt time.Time
+}
+
+// FieldChange holds the before and after value of a single field. A nil
+// value means the field was unset.
+type FieldChange struct {
+	Field  string  `json:"field"`
+	Before *string `json:"before"`
+	After  *string `json:"after"`
+}
+
+// FieldChanges is stored as a JSON array.
+type FieldChanges []FieldChange
+
+func (c FieldChanges) Value() (driver.Value, error) {
+	if c == nil {
+		c = FieldChanges{}
+	}
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+func (c *FieldChanges) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	case []byte:
+		return json.Unmarshal(v, c)
+	}
+	return errors.New("unsupported field changes value")
+}
+
+type HistoryPage struct {
+	Entries       []*HistoryEntry
+	NextPageToken string
+}
+
+// DiffTasks lists the fields that differ between two states of a task.
+// A nil before describes a creation and a nil after a removal.
+func DiffTasks(before, after *Task) FieldChanges {
+	var b, a Task
+	if before != nil {
+		b = *before
+	}
+	if after != nil {
+		a = *after
+	}
+	var changes FieldChanges
+	add := func(field string, before, after *string) {
+		if !equal(before, after) {
+			changes = append(changes, FieldChange{Field: field, Before: before, After: after})
+		}
+	}
+	add(FieldTitle, str(b.Title), str(a.Title))
+	add(FieldDescription, str(b.Description), str(a.Description))
+	add(FieldPriority, str(string(b.Priority)), str(string(a.Priority)))
+	add(FieldStatus, str(string(b.Status)), str(string(a.Status)))
+	add(FieldDueDate, timeStr(b.DueDate), timeStr(a.DueDate))
+	add(FieldTags, tagsStr(b.Tags), tagsStr(a.Tags))
+	add(FieldParent, idStr(b.ParentId), idStr(a.ParentId))
+	add(FieldSeries, idStr(b.SeriesId), idStr(a.SeriesId))
+	add(FieldOccurrence, intStr(b.Occurrence), intStr(a.Occurrence))
+	return changes
+}
+
+func str(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func timeStr(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.UTC().Format(time.RFC3339Nano)
+	return &s
+}
+
+func idStr(id *uint64) *string {
+	if id == nil {
+		return nil
+	}
+	s := strconv.FormatUint(*id, 10)
+	return &s
+}
+
+func intStr(n int) *string {
+	if n == 0 {
+		return nil
+	}
+	s := strconv.Itoa(n)
+	return &s
+}
+
+func tagsStr(tags TagNames) *string {
+	if len(tags) == 0 {
+		return nil
+	}
+	sorted := slices.Clone(tags)
+	slices.Sort(sorted)
+	s := strings.Join(sorted, ",")
+	return &s
+}
+
+func equal(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+type TaskSortField string
+
+const (
+	SortByCreatedAt TaskSortField = "created_at"
+	SortByDueDate   TaskSortField = "due_date"
+	SortByPriority  TaskSortField = "priority"
+	SortByDeletedAt TaskSortField = "deleted_at"
+)
+
+type TaskFilter struct {
+	Statuses   []Status
+	Priorities []Priority
+	DueFrom    *time.Time
+	DueTo      *time.Time
+	Tags       []string
+	TagMatch   TagMatch
+}
+
+type ListTasksQuery struct {
+	UserId    uint64
+	Trashed   bool
+	Filter    TaskFilter
+	SortBy    TaskSortField
+	Desc      bool
+	PageSize  int
+	PageToken string
+}
+
+type TaskPage struct {
+	Tasks         []*Task
+	NextPageToken string
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"cmp"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type Priority string
+
+const (
+	PriorityLow    Priority = "LOW"
+	PriorityMedium Priority = "MEDIUM"
+	PriorityHigh   Priority = "HIGH"
+)
+
+// Priorities lists every priority from lowest to highest.
+var Priorities = []Priority{PriorityLow, PriorityMedium, PriorityHigh}
+
+var ErrUnknownPriority = errors.New("unknown task priority")
+
+// ParsePriority accepts a priority name in any letter case.
+func ParsePriority(s string) (Priority, error) {
+	p := Priority(strings.ToUpper(strings.TrimSpace(s)))
+	if !p.Valid() {
+		return "", fmt.Errorf("%w: %q", ErrUnknownPriority, s)
+	}
+	return p, nil
+}
+
+func (p Priority) Valid() bool {
+	return slices.Contains(Priorities, p)
+}
+
+// Rank orders priorities so that HIGH ranks above MEDIUM above LOW. Unknown
+// priorities rank below all of them.
+func (p Priority) Rank() int {
+	return slices.Index(Priorities, p)
+}
+
+func (p Priority) Compare(other Priority) int {
+	return cmp.Compare(p.Rank(), other.Rank())
+}
+
+func (p Priority) String() string {
+	return string(p)
+}
+
+func (p Priority) Value() (driver.Value, error) {
+	if p == "" {
+		return nil, nil
+	}
+	return string(p), nil
+}
+
+func (p *Priority) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*p = ""
+		return nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return errors.New("unsupported priority value")
+	}
+	// Rows written before priorities were checked may hold an empty
+	// string; like NULL it reads as no priority.
+	if s == "" {
+		*p = ""
+		return nil
+	}
+	parsed, err := ParsePriority(s)
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPriorityScan(t *testing.T) {
+	tests := []struct {
+		src  any
+		want Priority
+	}{
+		{src: nil, want: ""},
+		{src: "", want: ""},
+		{src: []byte(""), want: ""},
+		{src: "HIGH", want: PriorityHigh},
+		{src: []byte("medium"), want: PriorityMedium},
+	}
+	for _, tt := range tests {
+		p := PriorityLow
+		if err := p.Scan(tt.src); err != nil || p != tt.want {
+			t.Errorf("Scan(%#v) = %q, %v, want %q", tt.src, p, err, tt.want)
+		}
+	}
+	var p Priority
+	if err := p.Scan("URGENT"); !errors.Is(err, ErrUnknownPriority) {
+		t.Errorf("Scan(URGENT) = %v, want %v", err, ErrUnknownPriority)
+	}
+}
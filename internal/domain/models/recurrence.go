@@ -0,0 +1,278 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	FreqDaily   Frequency = "DAILY"
+	FreqWeekly  Frequency = "WEEKLY"
+	FreqMonthly Frequency = "MONTHLY"
+	FreqYearly  Frequency = "YEARLY"
+)
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+// maxPeriods bounds the search for the next occurrence of rules that rarely
+// or never match, such as the 31st of every other month starting in February.
+const maxPeriods = 10000
+
+// WeekdayNum is a BYDAY entry. N selects the nth matching weekday of the
+// month, counting from the end when negative; zero matches every one.
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+// Recurrence is the supported subset of an iCalendar RRULE: FREQ, INTERVAL,
+// BYDAY and either COUNT or UNTIL. Ordinal BYDAY entries such as 1MO or -1FR
+// are only valid with FREQ=MONTHLY, and BYDAY is not valid with FREQ=YEARLY.
+type Recurrence struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []WeekdayNum
+	Count    int
+	Until    *time.Time
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// ParseRule parses an RRULE value such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR".
+// An optional "RRULE:" prefix is accepted.
+func ParseRule(rule string) (*Recurrence, error) {
+	r := &Recurrence{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:"), ";") {
+		key, value, ok := strings.Cut(part, "=")
+		key = strings.ToUpper(key)
+		if !ok || value == "" || seen[key] {
+			return nil, fmt.Errorf("%w: malformed or repeated part %q", ErrInvalidRule, part)
+		}
+		seen[key] = true
+		var err error
+		switch key {
+		case "FREQ":
+			r.Freq = Frequency(strings.ToUpper(value))
+			if !slices.Contains([]Frequency{FreqDaily, FreqWeekly, FreqMonthly, FreqYearly}, r.Freq) {
+				err = errors.New("unsupported FREQ")
+			}
+		case "INTERVAL":
+			r.Interval, err = positive(value)
+		case "COUNT":
+			r.Count, err = positive(value)
+		case "UNTIL":
+			var until time.Time
+			until, err = parseUntil(value)
+			r.Until = &until
+		case "BYDAY":
+			r.ByDay, err = parseByDay(value)
+		default:
+			err = errors.New("unsupported part")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidRule, key, err)
+		}
+	}
+	if err := r.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+	return r, nil
+}
+
+func (r *Recurrence) validate() error {
+	if r.Freq == "" {
+		return errors.New("FREQ is required")
+	}
+	if r.Count > 0 && r.Until != nil {
+		return errors.New("COUNT and UNTIL are mutually exclusive")
+	}
+	if len(r.ByDay) > 0 && r.Freq == FreqYearly {
+		return errors.New("BYDAY is not supported with FREQ=YEARLY")
+	}
+	for _, day := range r.ByDay {
+		if day.N != 0 && r.Freq != FreqMonthly {
+			return errors.New("ordinal BYDAY requires FREQ=MONTHLY")
+		}
+	}
+	return nil
+}
+
+// String formats the rule in canonical RRULE form.
+func (r *Recurrence) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = strings.ToUpper(day.Weekday.String()[:2])
+			if day.N != 0 {
+				days[i] = strconv.Itoa(day.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence of a series starting at start that
+// falls strictly after after, or false when the rule's UNTIL has passed or
+// no occurrence can be found. COUNT is not checked here because it depends
+// on how many occurrences the series already has.
+func (r *Recurrence) Next(start, after time.Time) (time.Time, bool) {
+	first := 0
+	// Daily and weekly periods have a fixed length, so far-away dates can
+	// be skipped to directly.
+	if days := r.periodDays(); days > 0 && after.After(start) {
+		first = max(int(after.Sub(start).Hours()/24)/days-1, 0)
+	}
+	for i := first; i < first+maxPeriods; i++ {
+		for _, candidate := range r.period(start, i) {
+			if candidate.Before(start) || !candidate.After(after) {
+				continue
+			}
+			if r.Until != nil && candidate.After(*r.Until) {
+				return time.Time{}, false
+			}
+			return candidate, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (r *Recurrence) periodDays() int {
+	switch r.Freq {
+	case FreqDaily:
+		return r.Interval
+	case FreqWeekly:
+		return 7 * r.Interval
+	}
+	return 0
+}
+
+// period lists the occurrences of the ith period in chronological order.
+func (r *Recurrence) period(start time.Time, i int) []time.Time {
+	y, m, d := start.Date()
+	hh, mm, ss := start.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hh, mm, ss, start.Nanosecond(), start.Location())
+	}
+	switch r.Freq {
+	case FreqDaily:
+		day := start.AddDate(0, 0, i*r.Interval)
+		if len(r.ByDay) > 0 && !r.hasWeekday(day.Weekday()) {
+			return nil
+		}
+		return []time.Time{day}
+	case FreqWeekly:
+		if len(r.ByDay) == 0 {
+			return []time.Time{start.AddDate(0, 0, 7*i*r.Interval)}
+		}
+		// Weeks start on Monday, the RRULE default for WKST.
+		monday := at(y, m, d-(int(start.Weekday())+6)%7+7*i*r.Interval)
+		var res []time.Time
+		for offset := range 7 {
+			if day := monday.AddDate(0, 0, offset); r.hasWeekday(day.Weekday()) {
+				res = append(res, day)
+			}
+		}
+		return res
+	case FreqMonthly:
+		month := time.Date(y, m+time.Month(i*r.Interval), 1, 0, 0, 0, 0, time.UTC)
+		if len(r.ByDay) == 0 {
+			// Months without the start day are skipped, as in RFC 5545.
+			if day := at(month.Year(), month.Month(), d); day.Day() == d {
+				return []time.Time{day}
+			}
+			return nil
+		}
+		return r.monthDays(month, at)
+	case FreqYearly:
+		if day := at(y+i*r.Interval, m, d); day.Day() == d {
+			return []time.Time{day}
+		}
+	}
+	return nil
+}
+
+// monthDays lists the days of month selected by BYDAY.
+func (r *Recurrence) monthDays(month time.Time, at func(int, time.Month, int) time.Time) []time.Time {
+	length := month.AddDate(0, 1, -1).Day()
+	var res []time.Time
+	for d := 1; d <= length; d++ {
+		weekday := time.Date(month.Year(), month.Month(), d, 0, 0, 0, 0, time.UTC).Weekday()
+		nth, nthLast := (d-1)/7+1, -((length-d)/7 + 1)
+		for _, day := range r.ByDay {
+			if day.Weekday == weekday && (day.N == 0 || day.N == nth || day.N == nthLast) {
+				res = append(res, at(month.Year(), month.Month(), d))
+				break
+			}
+		}
+	}
+	return res
+}
+
+func (r *Recurrence) hasWeekday(weekday time.Weekday) bool {
+	return slices.ContainsFunc(r.ByDay, func(day WeekdayNum) bool { return day.Weekday == weekday })
+}
+
+func positive(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, errors.New("must be a positive integer")
+	}
+	return n, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				// A date-only UNTIL includes the whole day.
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("must be YYYYMMDD or YYYYMMDDTHHMMSSZ")
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var res []WeekdayNum
+	for _, item := range strings.Split(strings.ToUpper(value), ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("bad day %q", item)
+		}
+		weekday, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("bad day %q", item)
+		}
+		day := WeekdayNum{Weekday: weekday}
+		if prefix := item[:len(item)-2]; prefix != "" {
+			n, err := strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, fmt.Errorf("bad day %q", item)
+			}
+			day.N = n
+		}
+		res = append(res, day)
+	}
+	return res, nil
+}
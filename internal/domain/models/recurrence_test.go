@@ -0,0 +1,181 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// expand lists the next n occurrences of a series starting at start.
+func expand(t *testing.T, rule string, start time.Time, n int) []time.Time {
+	t.Helper()
+	r, err := ParseRule(rule)
+	if err != nil {
+		t.Fatalf("ParseRule(%q): %v", rule, err)
+	}
+	var res []time.Time
+	for after := start; len(res) < n; {
+		next, ok := r.Next(start, after)
+		if !ok {
+			break
+		}
+		res = append(res, next)
+		after = next
+	}
+	return res
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	utc := func(y int, m time.Month, d, hh int) time.Time {
+		return time.Date(y, m, d, hh, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		want  []time.Time
+		// ended means no occurrence follows want.
+		ended bool
+	}{
+		{name: "daily", rule: "FREQ=DAILY;INTERVAL=2", start: utc(2025, time.January, 30, 9),
+			want: []time.Time{utc(2025, time.February, 1, 9), utc(2025, time.February, 3, 9)}},
+		{name: "daily on weekdays", rule: "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", start: utc(2025, time.January, 9, 9),
+			want: []time.Time{utc(2025, time.January, 10, 9), utc(2025, time.January, 13, 9)}},
+		{name: "weekly, several days", rule: "FREQ=WEEKLY;BYDAY=MO,WE,FR", start: utc(2025, time.January, 6, 9),
+			want: []time.Time{utc(2025, time.January, 8, 9), utc(2025, time.January, 10, 9),
+				utc(2025, time.January, 13, 9), utc(2025, time.January, 15, 9)}},
+		{name: "every other week, several days", rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH",
+			start: utc(2025, time.January, 7, 9),
+			want: []time.Time{utc(2025, time.January, 9, 9), utc(2025, time.January, 21, 9),
+				utc(2025, time.January, 23, 9), utc(2025, time.February, 4, 9)}},
+		{name: "weekly, days before the start's weekday", rule: "FREQ=WEEKLY;BYDAY=MO,FR",
+			start: utc(2025, time.January, 8, 9),
+			want:  []time.Time{utc(2025, time.January, 10, 9), utc(2025, time.January, 13, 9)}},
+		{name: "last friday", rule: "FREQ=MONTHLY;BYDAY=-1FR", start: utc(2025, time.January, 31, 9),
+			want: []time.Time{utc(2025, time.February, 28, 9), utc(2025, time.March, 28, 9),
+				utc(2025, time.April, 25, 9)}},
+		{name: "first monday", rule: "FREQ=MONTHLY;BYDAY=1MO", start: utc(2025, time.January, 6, 9),
+			want: []time.Time{utc(2025, time.February, 3, 9), utc(2025, time.March, 3, 9),
+				utc(2025, time.April, 7, 9)}},
+		{name: "second to last sunday", rule: "FREQ=MONTHLY;BYDAY=-2SU", start: utc(2025, time.March, 23, 9),
+			want: []time.Time{utc(2025, time.April, 20, 9), utc(2025, time.May, 18, 9)}},
+		{name: "month end skips short months", rule: "FREQ=MONTHLY", start: utc(2025, time.January, 31, 9),
+			want: []time.Time{utc(2025, time.March, 31, 9), utc(2025, time.May, 31, 9),
+				utc(2025, time.July, 31, 9), utc(2025, time.August, 31, 9)}},
+		{name: "month end every other month", rule: "FREQ=MONTHLY;INTERVAL=2", start: utc(2025, time.August, 31, 9),
+			want: []time.Time{utc(2025, time.October, 31, 9), utc(2025, time.December, 31, 9),
+				utc(2026, time.August, 31, 9)}},
+		{name: "leap day", rule: "FREQ=YEARLY", start: utc(2024, time.February, 29, 9),
+			want: []time.Time{utc(2028, time.February, 29, 9), utc(2032, time.February, 29, 9)}},
+		{name: "until a date includes that day", rule: "FREQ=DAILY;UNTIL=20250103", start: utc(2025, time.January, 1, 9),
+			want: []time.Time{utc(2025, time.January, 2, 9), utc(2025, time.January, 3, 9)}, ended: true},
+		{name: "until a time includes that time", rule: "FREQ=WEEKLY;UNTIL=20250115T090000Z",
+			start: utc(2025, time.January, 1, 9),
+			want:  []time.Time{utc(2025, time.January, 8, 9), utc(2025, time.January, 15, 9)}, ended: true},
+		{name: "until before the next one", rule: "FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20250227",
+			start: utc(2025, time.January, 31, 9), ended: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := len(tt.want)
+			if tt.ended {
+				n++
+			}
+			got := expand(t, tt.rule, tt.start, n)
+			if len(got) != len(tt.want) {
+				t.Fatalf("occurrences = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("occurrence %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRecurrenceNextKeepsLocalTimeAcrossDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 9, 0, 0, 0, ny)
+	}
+	tests := []struct {
+		rule  string
+		start time.Time
+		want  []time.Time
+	}{
+		// Clocks go forward on 2025-03-09 and back on 2025-11-02.
+		{rule: "FREQ=DAILY", start: local(2025, time.March, 8),
+			want: []time.Time{local(2025, time.March, 9), local(2025, time.March, 10)}},
+		{rule: "FREQ=WEEKLY;BYDAY=SA,SU", start: local(2025, time.November, 1),
+			want: []time.Time{local(2025, time.November, 2), local(2025, time.November, 8)}},
+		{rule: "FREQ=MONTHLY;BYDAY=-1FR", start: local(2025, time.October, 31),
+			want: []time.Time{local(2025, time.November, 28)}},
+	}
+	for _, tt := range tests {
+		got := expand(t, tt.rule, tt.start, len(tt.want))
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s: occurrences = %v, want %v", tt.rule, got, tt.want)
+		}
+		for i := range got {
+			if !got[i].Equal(tt.want[i]) || got[i].In(ny).Hour() != 9 {
+				t.Errorf("%s: occurrence %d = %v, want %v", tt.rule, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestRecurrenceNextSkipsAhead(t *testing.T) {
+	r, err := ParseRule("FREQ=WEEKLY;INTERVAL=3;BYDAY=MO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2025, time.January, 6, 9, 0, 0, 0, time.UTC)
+	got, ok := r.Next(start, time.Date(2030, time.June, 1, 0, 0, 0, 0, time.UTC))
+	want := time.Date(2030, time.June, 3, 9, 0, 0, 0, time.UTC)
+	if !ok || !got.Equal(want) {
+		t.Errorf("Next = %v, %v, want %v", got, ok, want)
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	valid := map[string]string{
+		"RRULE:freq=monthly;interval=2;byday=-1fr": "FREQ=MONTHLY;INTERVAL=2;BYDAY=-1FR",
+		"FREQ=WEEKLY;INTERVAL=1;BYDAY=MO,FR":       "FREQ=WEEKLY;BYDAY=MO,FR",
+		"FREQ=DAILY;COUNT=3":                       "FREQ=DAILY;COUNT=3",
+		"FREQ=DAILY;UNTIL=20250103":                "FREQ=DAILY;UNTIL=20250103T235959Z",
+	}
+	for rule, want := range valid {
+		r, err := ParseRule(rule)
+		if err != nil {
+			t.Errorf("ParseRule(%q): %v", rule, err)
+			continue
+		}
+		if r.String() != want {
+			t.Errorf("ParseRule(%q) = %s, want %s", rule, r, want)
+		}
+	}
+	invalid := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20250101",
+		"FREQ=DAILY;UNTIL=2025-01-01",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=YEARLY;BYDAY=MO",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=DAILY;BYMONTH=1",
+	}
+	for _, rule := range invalid {
+		if _, err := ParseRule(rule); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("ParseRule(%q) error = %v, want %v", rule, err, ErrInvalidRule)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Series links the occurrences of a recurring task. Rule is an RRULE in
+// canonical form and StartsAt the due date of the first occurrence.
+type Series struct {
+	Id        uint64
+	UserId    uint64
+	Rule      string
+	StartsAt  time.Time
+	Stopped   bool
+	CreatedAt time.Time
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Status is the progress state of a task. New tasks start as TODO; only
+// rows written before the status was defaulted may still hold
+// StatusUnspecified, which the workflow treats as TODO too.
+type Status string
+
+const (
+	StatusUnspecified Status = ""
+	StatusTodo        Status = "TODO"
+	StatusInProgress  Status = "IN_PROGRESS"
+	StatusDone        Status = "DONE"
+)
+
+// Statuses lists every status a task can be moved to.
+var Statuses = []Status{StatusTodo, StatusInProgress, StatusDone}
+
+var ErrUnknownStatus = errors.New("unknown task status")
+
+// ParseStatus accepts a status name in any letter case. The unspecified
+// status cannot be parsed since tasks cannot be moved to it.
+func ParseStatus(s string) (Status, error) {
+	status := Status(strings.ToUpper(strings.TrimSpace(s)))
+	if !status.Valid() {
+		return "", fmt.Errorf("%w: %q", ErrUnknownStatus, s)
+	}
+	return status, nil
+}
+
+func (s Status) Valid() bool {
+	return slices.Contains(Statuses, s)
+}
+
+func (s Status) String() string {
+	return string(s)
+}
+
+// Value stores the unspecified status as NULL.
+func (s Status) Value() (driver.Value, error) {
+	if s == StatusUnspecified {
+		return nil, nil
+	}
+	return string(s), nil
+}
+
+func (s *Status) Scan(src any) error {
+	var raw string
+	switch v := src.(type) {
+	case nil:
+		*s = StatusUnspecified
+		return nil
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return errors.New("unsupported status value")
+	}
+	if raw == "" {
+		*s = StatusUnspecified
+		return nil
+	}
+	parsed, err := ParseStatus(raw)
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
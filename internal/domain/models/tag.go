@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type Tag struct {
+	Id        uint64
+	UserId    uint64
+	Name      string
+	CreatedAt time.Time
+}
+
+// TagNames lists the names of the tags attached to a task. Storages read
+// it as a JSON array.
+type TagNames []string
+
+func (n *TagNames) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*n = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), n)
+	case []byte:
+		return json.Unmarshal(v, n)
+	}
+	return errors.New("unsupported tag names value")
+}
+
+type TagMatch string
+
+const (
+	// TagMatchAny keeps tasks carrying at least one of the filter tags.
+	TagMatchAny TagMatch = "any"
+	// TagMatchAll keeps tasks carrying every filter tag.
+	TagMatchAll TagMatch = "all"
+)
@@ -9,8 +9,14 @@ type Task struct {
 	UserId      uint64
 	Title       string
 	Description string
-	Priority    string
-	Status      string
+	Priority    Priority
+	Status      Status
 	CreatedAt   time.Time
-	DueDate     time.Time
+	DueDate     *time.Time
+	Version     uint64
+	DeletedAt   *time.Time
+	Tags        TagNames
+	ParentId    *uint64
+	SeriesId    *uint64
+	Occurrence  int
 }
@@ -0,0 +1,51 @@
+package models
+
+// TaskTree is a task together with its subtasks.
+type TaskTree struct {
+	Task     *Task
+	Children []*TaskTree
+	// Progress is the completion percentage of the task. A task without
+	// subtasks is 100 when DONE and 0 otherwise; a parent averages the
+	// progress of its children.
+	Progress int
+}
+
+// BuildTree arranges the tasks of a subtree under root. Tasks whose parent
+// is not part of the subtree are ignored. Children keep the order in which
+// they appear in tasks.
+func BuildTree(root uint64, tasks []*Task) *TaskTree {
+	nodes := make(map[uint64]*TaskTree, len(tasks))
+	for _, task := range tasks {
+		nodes[task.Id] = &TaskTree{Task: task}
+	}
+	for _, task := range tasks {
+		if task.Id == root || task.ParentId == nil {
+			continue
+		}
+		if parent, ok := nodes[*task.ParentId]; ok {
+			parent.Children = append(parent.Children, nodes[task.Id])
+		}
+	}
+	tree, ok := nodes[root]
+	if !ok {
+		return nil
+	}
+	tree.rollUp()
+	return tree
+}
+
+func (t *TaskTree) rollUp() int {
+	if len(t.Children) == 0 {
+		t.Progress = 0
+		if t.Task.Status == StatusDone {
+			t.Progress = 100
+		}
+		return t.Progress
+	}
+	total := 0
+	for _, child := range t.Children {
+		total += child.rollUp()
+	}
+	t.Progress = total / len(t.Children)
+	return t.Progress
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Task field paths used in update masks and change history.
+const (
+	FieldTitle       = "title"
+	FieldDescription = "description"
+	FieldPriority    = "priority"
+	FieldDueDate     = "due_date"
+	FieldStatus      = "status"
+	FieldTags        = "tags"
+	FieldParent      = "parent_id"
+	FieldSeries      = "series_id"
+	FieldOccurrence  = "occurrence"
+)
+
+var UpdatableFields = []string{FieldTitle, FieldDescription, FieldPriority, FieldDueDate}
+
+// TaskUpdate carries new values for exactly the fields named in Mask.
+// Fields outside the mask are ignored, so an empty Description or a nil
+// DueDate listed in the mask clears that field. AddTags and RemoveTags are
+// applied independently of the mask.
+type TaskUpdate struct {
+	Mask        []string
+	Title       string
+	Description string
+	Priority    Priority
+	DueDate     *time.Time
+	AddTags     []string
+	RemoveTags  []string
+}
+
+func (u TaskUpdate) Has(field string) bool {
+	for _, f := range u.Mask {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
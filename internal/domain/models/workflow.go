@@ -0,0 +1,61 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+)
+
+// DefaultTransitions lets work start, finish and be reopened, but a done
+// task cannot go straight back to TODO.
+var DefaultTransitions = map[Status][]Status{
+	StatusTodo:       {StatusInProgress, StatusDone},
+	StatusInProgress: {StatusTodo, StatusDone},
+	StatusDone:       {StatusInProgress},
+}
+
+// Workflow is the table of allowed status transitions. Tasks that were
+// never given a status are treated as TODO, and moving a task to the status
+// it already has is always allowed.
+type Workflow struct {
+	transitions map[Status][]Status
+}
+
+// NewWorkflow parses a transition table keyed by status name. A nil or
+// empty table selects DefaultTransitions.
+func NewWorkflow(transitions map[string][]string) (*Workflow, error) {
+	if len(transitions) == 0 {
+		return &Workflow{transitions: DefaultTransitions}, nil
+	}
+	res := make(map[Status][]Status, len(transitions))
+	for from, to := range transitions {
+		fromStatus, err := ParseStatus(from)
+		if err != nil {
+			return nil, fmt.Errorf("workflow: %w", err)
+		}
+		for _, name := range to {
+			status, err := ParseStatus(name)
+			if err != nil {
+				return nil, fmt.Errorf("workflow: %w", err)
+			}
+			res[fromStatus] = append(res[fromStatus], status)
+		}
+	}
+	return &Workflow{transitions: res}, nil
+}
+
+func (w *Workflow) Allowed(from, to Status) bool {
+	from = initial(from)
+	return from == to || slices.Contains(w.transitions[from], to)
+}
+
+// Next lists the statuses a task in status from may move to.
+func (w *Workflow) Next(from Status) []Status {
+	return slices.Clone(w.transitions[initial(from)])
+}
+
+func initial(status Status) Status {
+	if status == StatusUnspecified {
+		return StatusTodo
+	}
+	return status
+}
@@ -2,57 +2,234 @@ package converter
 
 import (
 	"errors"
+	"fmt"
 	taskv1 "github.com/Citadelas/protos/golang/task"
 	"github.com/Citadelas/task/internal/domain/models"
 	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
 )
 
 var (
-	ErrUnknownStatus   = errors.New("unknown task status")
-	ErrUnknownPriority = errors.New("unknown task priority")
+	ErrUnknownStatus   = models.ErrUnknownStatus
+	ErrUnknownPriority = models.ErrUnknownPriority
+	ErrInvalidDueDate  = errors.New("invalid due date")
+	ErrNilTask         = errors.New("task is nil")
 )
 
+var priorities = map[models.Priority]taskv1.TaskPriority{
+	models.PriorityLow:    taskv1.TaskPriority_LOW,
+	models.PriorityMedium: taskv1.TaskPriority_MEDIUM,
+	models.PriorityHigh:   taskv1.TaskPriority_HIGH,
+}
+
+var statuses = map[models.Status]taskv1.TaskStatus{
+	models.StatusUnspecified: taskv1.TaskStatus_TASK_STATUS_UNSPECIFIED,
+	models.StatusTodo:        taskv1.TaskStatus_TODO,
+	models.StatusInProgress:  taskv1.TaskStatus_IN_PROGRESS,
+	models.StatusDone:        taskv1.TaskStatus_DONE,
+}
+
 type TaskAdapter struct{}
 
 func NewTaskAdapter() *TaskAdapter {
 	return &TaskAdapter{}
 }
 
+// ToProto maps every field of Task that the proto message carries. Unset
+// timestamps stay nil, and a task stored without a priority is reported as
+// LOW, the proto default.
 func (a *TaskAdapter) ToProto(domainTask *models.Task) (*taskv1.Task, error) {
-	statusVal, ok := taskv1.TaskStatus_value[domainTask.Status]
-	if !ok {
-		if domainTask.Status != "" {
-			return nil, ErrUnknownStatus
-		}
-		statusVal = taskv1.TaskStatus_value["TASK_STATUS_UNSPECIFIED"]
+	if domainTask == nil {
+		return nil, ErrNilTask
 	}
-	status := taskv1.TaskStatus(statusVal)
-
-	priorityVal, ok := taskv1.TaskPriority_value[domainTask.Priority]
-	if !ok {
-		return nil, ErrUnknownPriority
+	status, err := StatusToProto(domainTask.Status)
+	if err != nil {
+		return nil, err
+	}
+	priority, err := PriorityToProto(domainTask.Priority)
+	if err != nil {
+		return nil, err
 	}
 	return &taskv1.Task{
 		Id:          domainTask.Id,
 		UserId:      domainTask.UserId,
 		Title:       domainTask.Title,
 		Description: domainTask.Description,
-		Priority:    taskv1.TaskPriority(priorityVal),
+		Priority:    priority,
 		Status:      status,
-		CreatedAt:   timestamppb.New(domainTask.CreatedAt),
-		DueDate:     timestamppb.New(domainTask.DueDate),
+		CreatedAt:   createdAtToProto(domainTask.CreatedAt),
+		DueDate:     timeToProto(domainTask.DueDate),
 	}, nil
 }
 
+// ToDomain is the inverse of ToProto. An unset created_at becomes the zero
+// time rather than the Unix epoch.
 func (a *TaskAdapter) ToDomain(protoTask *taskv1.Task) (*models.Task, error) {
+	if protoTask == nil {
+		return nil, ErrNilTask
+	}
+	status, err := StatusFromProto(protoTask.GetStatus())
+	if err != nil {
+		return nil, err
+	}
+	priority, err := PriorityFromProto(protoTask.GetPriority())
+	if err != nil {
+		return nil, err
+	}
 	return &models.Task{
 		Id:          protoTask.Id,
 		UserId:      protoTask.UserId,
 		Title:       protoTask.Title,
 		Description: protoTask.Description,
-		Status:      protoTask.String(),
-		Priority:    protoTask.String(),
-		CreatedAt:   protoTask.CreatedAt.AsTime(),
-		DueDate:     protoTask.DueDate.AsTime(),
+		Status:      status,
+		Priority:    priority,
+		CreatedAt:   createdAtFromProto(protoTask.GetCreatedAt()),
+		DueDate:     timeFromProto(protoTask.GetDueDate()),
+	}, nil
+}
+
+// CreateFromProto builds the task described by a CreateTaskRequest. An
+// epoch due date means no due date, as it does for updates.
+func CreateFromProto(req *taskv1.CreateTaskRequest) (*models.Task, error) {
+	dueDate, _, err := DueDateFromProto(req.GetDueDate())
+	if err != nil {
+		return nil, err
+	}
+	priority, err := PriorityFromProto(req.GetPriority())
+	if err != nil {
+		return nil, err
+	}
+	return &models.Task{
+		UserId:      req.GetUserId(),
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		Priority:    priority,
+		DueDate:     dueDate,
 	}, nil
 }
+
+// PriorityToProto maps an empty priority to LOW. The proto enum has no
+// unspecified value (LOW is 0), so this direction is lossy: a task stored
+// without a priority comes back from ToDomain as LOW.
+func PriorityToProto(priority models.Priority) (taskv1.TaskPriority, error) {
+	if priority == "" {
+		return taskv1.TaskPriority_LOW, nil
+	}
+	res, ok := priorities[priority]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownPriority, priority)
+	}
+	return res, nil
+}
+
+func PriorityFromProto(priority taskv1.TaskPriority) (models.Priority, error) {
+	for domain, proto := range priorities {
+		if proto == priority {
+			return domain, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %d", ErrUnknownPriority, priority)
+}
+
+// StatusToProto maps the unspecified status to TASK_STATUS_UNSPECIFIED.
+func StatusToProto(status models.Status) (taskv1.TaskStatus, error) {
+	res, ok := statuses[status]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownStatus, status)
+	}
+	return res, nil
+}
+
+func StatusFromProto(status taskv1.TaskStatus) (models.Status, error) {
+	for domain, proto := range statuses {
+		if proto == status {
+			return domain, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %d", ErrUnknownStatus, status)
+}
+
+// DueDateFromProto interprets the due date of a create or update request.
+// An unset timestamp leaves the due date untouched, while an explicitly
+// zero timestamp (the Unix epoch) asks for the due date to be removed, so
+// a due date of exactly the epoch cannot be sent.
+func DueDateFromProto(ts *timestamppb.Timestamp) (dueDate *time.Time, clear bool, err error) {
+	if ts == nil {
+		return nil, false, nil
+	}
+	if ts.GetSeconds() == 0 && ts.GetNanos() == 0 {
+		return nil, true, nil
+	}
+	if err := ts.CheckValid(); err != nil {
+		return nil, false, ErrInvalidDueDate
+	}
+	t := ts.AsTime()
+	return &t, false, nil
+}
+
+func timeToProto(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func createdAtToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func createdAtFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+func timeFromProto(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+// UpdateFromProto builds a domain update from an UpdateTaskRequest. When
+// mask is empty the legacy behavior applies: only non-empty title,
+// description, non-LOW priority and a set due date are changed, and an
+// epoch due date clears it.
+func UpdateFromProto(req *taskv1.UpdateTaskRequest, mask []string) (models.TaskUpdate, error) {
+	dueDate, clearDueDate, err := DueDateFromProto(req.GetDueDate())
+	if err != nil {
+		return models.TaskUpdate{}, err
+	}
+	priority, err := PriorityFromProto(req.GetPriority())
+	if err != nil {
+		return models.TaskUpdate{}, err
+	}
+	update := models.TaskUpdate{
+		Mask:        mask,
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		Priority:    priority,
+		DueDate:     dueDate,
+	}
+	if len(mask) > 0 {
+		return update, nil
+	}
+	if update.Title != "" {
+		update.Mask = append(update.Mask, models.FieldTitle)
+	}
+	if update.Description != "" {
+		update.Mask = append(update.Mask, models.FieldDescription)
+	}
+	if req.GetPriority() != taskv1.TaskPriority_LOW {
+		update.Mask = append(update.Mask, models.FieldPriority)
+	}
+	if dueDate != nil || clearDueDate {
+		update.Mask = append(update.Mask, models.FieldDueDate)
+	}
+	return update, nil
+}
@@ -0,0 +1,341 @@
+package converter
+
+import (
+	"errors"
+	taskv1 "github.com/Citadelas/protos/golang/task"
+	"github.com/Citadelas/task/internal/domain/models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPriorityToProto(t *testing.T) {
+	tests := []struct {
+		in      models.Priority
+		want    taskv1.TaskPriority
+		wantErr error
+	}{
+		{in: models.PriorityLow, want: taskv1.TaskPriority_LOW},
+		{in: models.PriorityMedium, want: taskv1.TaskPriority_MEDIUM},
+		{in: models.PriorityHigh, want: taskv1.TaskPriority_HIGH},
+		// Lossy: the proto enum has no unspecified value.
+		{in: "", want: taskv1.TaskPriority_LOW},
+		{in: "URGENT", wantErr: ErrUnknownPriority},
+	}
+	for _, tt := range tests {
+		got, err := PriorityToProto(tt.in)
+		if !errors.Is(err, tt.wantErr) {
+			t.Fatalf("PriorityToProto(%q) error = %v, want %v", tt.in, err, tt.wantErr)
+		}
+		if err == nil && got != tt.want {
+			t.Errorf("PriorityToProto(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPriorityFromProto(t *testing.T) {
+	for _, p := range models.Priorities {
+		proto, err := PriorityToProto(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := PriorityFromProto(proto)
+		if err != nil || got != p {
+			t.Errorf("PriorityFromProto(%v) = %q, %v, want %q", proto, got, err, p)
+		}
+	}
+	if _, err := PriorityFromProto(taskv1.TaskPriority(42)); !errors.Is(err, ErrUnknownPriority) {
+		t.Errorf("PriorityFromProto(42) error = %v, want %v", err, ErrUnknownPriority)
+	}
+}
+
+func TestStatusRoundTrip(t *testing.T) {
+	tests := []struct {
+		in   models.Status
+		want taskv1.TaskStatus
+	}{
+		{in: models.StatusUnspecified, want: taskv1.TaskStatus_TASK_STATUS_UNSPECIFIED},
+		{in: models.StatusTodo, want: taskv1.TaskStatus_TODO},
+		{in: models.StatusInProgress, want: taskv1.TaskStatus_IN_PROGRESS},
+		{in: models.StatusDone, want: taskv1.TaskStatus_DONE},
+	}
+	for _, tt := range tests {
+		got, err := StatusToProto(tt.in)
+		if err != nil || got != tt.want {
+			t.Fatalf("StatusToProto(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+		back, err := StatusFromProto(got)
+		if err != nil || back != tt.in {
+			t.Errorf("StatusFromProto(%v) = %q, %v, want %q", got, back, err, tt.in)
+		}
+	}
+	if _, err := StatusToProto("BLOCKED"); !errors.Is(err, ErrUnknownStatus) {
+		t.Errorf("StatusToProto(BLOCKED) error = %v, want %v", err, ErrUnknownStatus)
+	}
+	if _, err := StatusFromProto(taskv1.TaskStatus(42)); !errors.Is(err, ErrUnknownStatus) {
+		t.Errorf("StatusFromProto(42) error = %v, want %v", err, ErrUnknownStatus)
+	}
+}
+
+func TestDueDateFromProto(t *testing.T) {
+	due := time.Date(2030, time.March, 1, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		in        *timestamppb.Timestamp
+		want      *time.Time
+		wantClear bool
+		wantErr   error
+	}{
+		{name: "unset", in: nil},
+		{name: "set", in: timestamppb.New(due), want: &due},
+		// Lossy: the epoch itself cannot be sent as a due date.
+		{name: "epoch clears", in: &timestamppb.Timestamp{}, wantClear: true},
+		{name: "invalid", in: &timestamppb.Timestamp{Seconds: 1, Nanos: -1}, wantErr: ErrInvalidDueDate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, clear, err := DueDateFromProto(tt.in)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if clear != tt.wantClear {
+				t.Errorf("clear = %v, want %v", clear, tt.wantClear)
+			}
+			if !equalTime(got, tt.want) {
+				t.Errorf("due date = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateFromProto(t *testing.T) {
+	due := time.Date(2030, time.March, 1, 0, 0, 0, 0, time.UTC)
+	full := &taskv1.UpdateTaskRequest{
+		Id:          1,
+		UserId:      2,
+		Title:       "title",
+		Description: "description",
+		Priority:    taskv1.TaskPriority_HIGH,
+		DueDate:     timestamppb.New(due),
+	}
+	tests := []struct {
+		name     string
+		req      *taskv1.UpdateTaskRequest
+		mask     []string
+		wantMask []string
+		wantDue  *time.Time
+	}{
+		{name: "no mask, all set", req: full,
+			wantMask: []string{models.FieldTitle, models.FieldDescription, models.FieldPriority, models.FieldDueDate},
+			wantDue:  &due},
+		{name: "no mask, nothing set", req: &taskv1.UpdateTaskRequest{Id: 1}},
+		{name: "no mask, low priority is not a change", req: &taskv1.UpdateTaskRequest{Id: 1, Title: "t"},
+			wantMask: []string{models.FieldTitle}},
+		{name: "no mask, epoch clears due date", req: &taskv1.UpdateTaskRequest{Id: 1, DueDate: &timestamppb.Timestamp{}},
+			wantMask: []string{models.FieldDueDate}},
+		{name: "mask is kept as is", req: full, mask: []string{models.FieldTitle},
+			wantMask: []string{models.FieldTitle}, wantDue: &due},
+		{name: "mask clears empty fields", req: &taskv1.UpdateTaskRequest{Id: 1},
+			mask:     []string{models.FieldDescription, models.FieldDueDate},
+			wantMask: []string{models.FieldDescription, models.FieldDueDate}},
+		{name: "mask names every field", req: full, mask: models.UpdatableFields,
+			wantMask: models.UpdatableFields, wantDue: &due},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UpdateFromProto(tt.req, tt.mask)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got.Mask, tt.wantMask) {
+				t.Errorf("mask = %v, want %v", got.Mask, tt.wantMask)
+			}
+			if !equalTime(got.DueDate, tt.wantDue) {
+				t.Errorf("due date = %v, want %v", got.DueDate, tt.wantDue)
+			}
+			if got.Title != tt.req.GetTitle() || got.Description != tt.req.GetDescription() {
+				t.Errorf("text fields = %q, %q", got.Title, got.Description)
+			}
+		})
+	}
+	if _, err := UpdateFromProto(&taskv1.UpdateTaskRequest{Priority: 42}, nil); !errors.Is(err, ErrUnknownPriority) {
+		t.Errorf("unknown priority error = %v", err)
+	}
+}
+
+func TestNilTask(t *testing.T) {
+	a := NewTaskAdapter()
+	if _, err := a.ToProto(nil); !errors.Is(err, ErrNilTask) {
+		t.Errorf("ToProto(nil) error = %v", err)
+	}
+	if _, err := a.ToDomain(nil); !errors.Is(err, ErrNilTask) {
+		t.Errorf("ToDomain(nil) error = %v", err)
+	}
+}
+
+// FuzzTaskRoundTrip checks that ToDomain inverts ToProto for every task the
+// proto can represent: one with a priority and a due date other than the
+// epoch.
+func FuzzTaskRoundTrip(f *testing.F) {
+	f.Add(uint64(1), uint64(2), "title", "description", uint8(0), uint8(0), int64(1700000000123456789), int64(0), false)
+	f.Add(uint64(0), uint64(0), "", "", uint8(2), uint8(3), int64(0), int64(1900000000000000000), true)
+	f.Fuzz(func(t *testing.T, id, uid uint64, title, description string, p, s uint8,
+		createdAt, dueDate int64, hasDue bool) {
+		task := &models.Task{
+			Id:          id,
+			UserId:      uid,
+			Title:       title,
+			Description: description,
+			Priority:    models.Priorities[int(p)%len(models.Priorities)],
+			Status: []models.Status{models.StatusUnspecified, models.StatusTodo, models.StatusInProgress,
+				models.StatusDone}[int(s)%4],
+		}
+		if createdAt != 0 {
+			task.CreatedAt = time.Unix(0, createdAt).UTC()
+		}
+		if hasDue {
+			due := time.Unix(0, dueDate).UTC()
+			task.DueDate = &due
+		}
+		a := NewTaskAdapter()
+		proto, err := a.ToProto(task)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := a.ToDomain(proto)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Id != task.Id || got.UserId != task.UserId || got.Title != task.Title ||
+			got.Description != task.Description || got.Priority != task.Priority || got.Status != task.Status ||
+			!got.CreatedAt.Equal(task.CreatedAt) || !equalTime(got.DueDate, task.DueDate) {
+			t.Errorf("round trip = %+v, want %+v", got, task)
+		}
+	})
+}
+
+// FuzzCreateFromProto checks that a create request keeps every field,
+// except the epoch due date, which means "no due date".
+func FuzzCreateFromProto(f *testing.F) {
+	f.Add(uint64(1), "title", "description", int32(1), int64(1900000000), int32(5), true)
+	f.Add(uint64(0), "", "", int32(7), int64(0), int32(0), true)
+	f.Fuzz(func(t *testing.T, uid uint64, title, description string, priority int32, secs int64, nanos int32,
+		hasDue bool) {
+		req := &taskv1.CreateTaskRequest{
+			UserId:      uid,
+			Title:       title,
+			Description: description,
+			Priority:    taskv1.TaskPriority(priority),
+		}
+		if hasDue {
+			req.DueDate = &timestamppb.Timestamp{Seconds: secs, Nanos: nanos}
+		}
+		got, err := CreateFromProto(req)
+		_, known := taskv1.TaskPriority_name[priority]
+		switch {
+		case hasDue && (secs != 0 || nanos != 0) && req.DueDate.CheckValid() != nil:
+			if !errors.Is(err, ErrInvalidDueDate) {
+				t.Fatalf("invalid due date: error = %v", err)
+			}
+			return
+		case !known:
+			if !errors.Is(err, ErrUnknownPriority) {
+				t.Fatalf("priority %d: error = %v", priority, err)
+			}
+			return
+		case err != nil:
+			t.Fatal(err)
+		}
+		back, err := PriorityToProto(got.Priority)
+		if err != nil || back != req.Priority {
+			t.Errorf("priority = %q, want %v", got.Priority, req.Priority)
+		}
+		if got.UserId != uid || got.Title != title || got.Description != description {
+			t.Errorf("fields = %+v, want %+v", got, req)
+		}
+		var want *time.Time
+		if hasDue && (secs != 0 || nanos != 0) {
+			due := req.DueDate.AsTime()
+			want = &due
+		}
+		if !equalTime(got.DueDate, want) {
+			t.Errorf("due date = %v, want %v", got.DueDate, want)
+		}
+	})
+}
+
+// FuzzUpdateFromProto checks that with a mask every masked field carries
+// the request's value.
+func FuzzUpdateFromProto(f *testing.F) {
+	f.Add("title", "description", int32(2), int64(1900000000), uint8(0b1111))
+	f.Add("", "", int32(0), int64(0), uint8(0b0100))
+	f.Fuzz(func(t *testing.T, title, description string, priority int32, secs int64, fields uint8) {
+		if _, ok := taskv1.TaskPriority_name[priority]; !ok {
+			t.Skip()
+		}
+		req := &taskv1.UpdateTaskRequest{
+			Id:          1,
+			Title:       title,
+			Description: description,
+			Priority:    taskv1.TaskPriority(priority),
+			DueDate:     &timestamppb.Timestamp{Seconds: secs},
+		}
+		if req.DueDate.CheckValid() != nil {
+			t.Skip()
+		}
+		var mask []string
+		for i, field := range models.UpdatableFields {
+			if fields&(1<<i) != 0 {
+				mask = append(mask, field)
+			}
+		}
+		got, err := UpdateFromProto(req, mask)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(mask) > 0 && !slices.Equal(got.Mask, mask) {
+			t.Errorf("mask = %v, want %v", got.Mask, mask)
+		}
+		back, err := PriorityToProto(got.Priority)
+		if got.Title != title || got.Description != description || err != nil || back != req.Priority {
+			t.Errorf("update = %+v, want %+v", got, req)
+		}
+		if (secs == 0) != (got.DueDate == nil) {
+			t.Errorf("due date = %v for %d seconds", got.DueDate, secs)
+		}
+	})
+}
+
+// FuzzStatusFromProto checks that every status in an UpdateStatusRequest
+// either converts and maps back to itself or is rejected.
+func FuzzStatusFromProto(f *testing.F) {
+	for s := range taskv1.TaskStatus_name {
+		f.Add(s)
+	}
+	f.Add(int32(-1))
+	f.Fuzz(func(t *testing.T, s int32) {
+		req := &taskv1.UpdateStatusRequest{Id: 1, UserId: 2, Status: taskv1.TaskStatus(s)}
+		got, err := StatusFromProto(req.GetStatus())
+		if _, ok := taskv1.TaskStatus_name[s]; !ok {
+			if !errors.Is(err, ErrUnknownStatus) {
+				t.Fatalf("status %d: error = %v", s, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		back, err := StatusToProto(got)
+		if err != nil || back != req.Status {
+			t.Errorf("status %v came back as %v", req.Status, back)
+		}
+	})
+}
+
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
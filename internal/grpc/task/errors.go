@@ -0,0 +1,227 @@
+package TaskService
+
+import (
+	"context"
+	"errors"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/grpc/converter"
+	"github.com/Citadelas/task/internal/grpc/validation"
+	"github.com/Citadelas/task/internal/lib/logger/sl"
+	"github.com/Citadelas/task/internal/lib/requestid"
+	taskservice "github.com/Citadelas/task/internal/services/task"
+	"github.com/Citadelas/task/internal/storage"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// errorDomain names this service in the google.rpc.ErrorInfo details
+// attached to errors; together with the reason it forms a stable code
+// clients can switch on instead of parsing messages.
+const errorDomain = "task.citadelas"
+
+const (
+	reasonTaskNotFound      = "TASK_NOT_FOUND"
+	reasonVersionConflict   = "VERSION_CONFLICT"
+	reasonInputTooLong      = "INPUT_TOO_LONG"
+	reasonTaskBlocked       = "TASK_BLOCKED"
+	reasonInvalidTransition = "INVALID_STATUS_TRANSITION"
+	reasonInvalidMask       = "INVALID_UPDATE_MASK"
+	reasonInvalidIfMatch    = "INVALID_IF_MATCH"
+	reasonUnknownPriority   = "UNKNOWN_PRIORITY"
+	reasonUnknownStatus     = "UNKNOWN_STATUS"
+	reasonInvalidDueDate    = "INVALID_DUE_DATE"
+	reasonInternal          = "INTERNAL"
+	reasonInvalidPageToken  = "INVALID_PAGE_TOKEN"
+	reasonInvalidTag        = "INVALID_TAG"
+	reasonTagExists         = "TAG_EXISTS"
+	reasonTagNotFound       = "TAG_NOT_FOUND"
+	reasonBlockerNotFound   = "BLOCKER_NOT_FOUND"
+	reasonDependencyCycle   = "DEPENDENCY_CYCLE"
+	reasonParentNotFound    = "PARENT_NOT_FOUND"
+	reasonHierarchyCycle    = "HIERARCHY_CYCLE"
+	reasonSeriesNotFound    = "SERIES_NOT_FOUND"
+	reasonInvalidRecurrence = "INVALID_RECURRENCE"
+)
+
+// errorMapping describes how a sentinel error reaches clients. Errors tied
+// to one request field are reported as a BadRequest field violation, all
+// others carry an ErrorInfo. An empty message keeps the error's own text.
+type errorMapping struct {
+	err     error
+	code    codes.Code
+	reason  string
+	field   string
+	message string
+}
+
+var errorMappings = []errorMapping{
+	{err: taskservice.ErrWrongId, code: codes.NotFound, reason: reasonTaskNotFound, message: "task not found"},
+	{err: storage.ErrTaskNotFound, code: codes.NotFound, reason: reasonTaskNotFound, message: "task not found"},
+	{err: taskservice.ErrVersionConflict, code: codes.Aborted, reason: reasonVersionConflict,
+		message: taskservice.ErrVersionConflict.Error()},
+	{err: storage.ErrInputTooLong, code: codes.InvalidArgument, reason: reasonInputTooLong,
+		message: storage.ErrInputTooLong.Error()},
+	{err: taskservice.ErrTaskBlocked, code: codes.FailedPrecondition, reason: reasonTaskBlocked},
+	{err: taskservice.ErrInvalidTransition, code: codes.FailedPrecondition, reason: reasonInvalidTransition},
+	{err: taskservice.ErrInvalidMask, code: codes.InvalidArgument, reason: reasonInvalidMask, field: updateMaskHeader},
+	{err: errInvalidUpdateMask, code: codes.InvalidArgument, reason: reasonInvalidMask, field: updateMaskHeader},
+	{err: errInvalidIfMatch, code: codes.InvalidArgument, reason: reasonInvalidIfMatch, field: ifMatchHeader},
+	{err: models.ErrUnknownPriority, code: codes.InvalidArgument, reason: reasonUnknownPriority, field: "priority"},
+	{err: models.ErrUnknownStatus, code: codes.InvalidArgument, reason: reasonUnknownStatus, field: "status"},
+	{err: converter.ErrInvalidDueDate, code: codes.InvalidArgument, reason: reasonInvalidDueDate, field: "due_date"},
+	{err: taskservice.ErrInvalidPageToken, code: codes.InvalidArgument, reason: reasonInvalidPageToken,
+		field: "page_token", message: taskservice.ErrInvalidPageToken.Error()},
+	{err: taskservice.ErrInvalidTag, code: codes.InvalidArgument, reason: reasonInvalidTag, field: "tags"},
+	{err: taskservice.ErrTagExists, code: codes.AlreadyExists, reason: reasonTagExists,
+		message: taskservice.ErrTagExists.Error()},
+	{err: taskservice.ErrWrongTagId, code: codes.NotFound, reason: reasonTagNotFound, message: "tag not found"},
+	{err: taskservice.ErrWrongBlockerId, code: codes.NotFound, reason: reasonBlockerNotFound,
+		message: "blocker not found"},
+	{err: taskservice.ErrDependencyCycle, code: codes.FailedPrecondition, reason: reasonDependencyCycle,
+		message: taskservice.ErrDependencyCycle.Error()},
+	{err: taskservice.ErrWrongParentId, code: codes.NotFound, reason: reasonParentNotFound,
+		message: "parent task not found"},
+	{err: taskservice.ErrHierarchyCycle, code: codes.FailedPrecondition, reason: reasonHierarchyCycle,
+		message: taskservice.ErrHierarchyCycle.Error()},
+	{err: taskservice.ErrWrongSeriesId, code: codes.NotFound, reason: reasonSeriesNotFound,
+		message: "series not found"},
+	{err: taskservice.ErrInvalidRecurrence, code: codes.InvalidArgument, reason: reasonInvalidRecurrence,
+		field: "rule"},
+}
+
+// UnaryErrorInterceptor translates the errors handlers return into gRPC
+// statuses in one place. Statuses pass through unchanged, known errors get
+// their mapped code, and anything else is logged with a correlation id and
+// reported as a bare Internal error carrying only that id.
+func UnaryErrorInterceptor(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, TranslateError(ctx, log.With(slog.String("method", info.FullMethod)), err)
+		}
+		return resp, nil
+	}
+}
+
+// StreamErrorInterceptor is UnaryErrorInterceptor for streaming calls.
+func StreamErrorInterceptor(log *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return TranslateError(ss.Context(), log.With(slog.String("method", info.FullMethod)), err)
+		}
+		return nil
+	}
+}
+
+// TranslateError turns an error returned by a handler into a status.
+// Unexpected errors use the request id as correlation id when the call has
+// one, so the client-visible id matches the request log line.
+func TranslateError(ctx context.Context, log *slog.Logger, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if st, ok := errorStatus(err); ok {
+		return st
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, context.Canceled.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, context.DeadlineExceeded.Error())
+	}
+	id := requestid.FromContext(ctx)
+	if id == "" {
+		id = requestid.New()
+	}
+	log.ErrorContext(ctx, "internal error", slog.String("correlation_id", id), sl.Err(err))
+	st := status.Newf(codes.Internal, "internal error (correlation id %s)", id)
+	detailed, detailsErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   reasonInternal,
+		Domain:   errorDomain,
+		Metadata: map[string]string{"correlation_id": id},
+	})
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// errorStatus converts a known service, storage or decoding error into a
+// status with details; ok is false for anything else.
+func errorStatus(err error) (error, bool) {
+	for _, m := range errorMappings {
+		if !errors.Is(err, m.err) {
+			continue
+		}
+		message := m.message
+		if message == "" {
+			message = errorMessage(err)
+		}
+		if m.field != "" {
+			return validation.FieldError(m.field, m.reason, errors.New(message)), true
+		}
+		st := status.New(m.code, message)
+		detailed, detailsErr := st.WithDetails(&errdetails.ErrorInfo{
+			Reason:   m.reason,
+			Domain:   errorDomain,
+			Metadata: errorMetadata(err),
+		})
+		if detailsErr != nil {
+			return st.Err(), true
+		}
+		return detailed.Err(), true
+	}
+	return nil, false
+}
+
+// InvalidArgument reports a request that could not be decoded.
+func InvalidArgument(err error) error {
+	if st, ok := errorStatus(err); ok {
+		return st
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+// errorMessage prefers the text of typed service errors, which describe
+// the problem without the operation prefixes added on the way up.
+func errorMessage(err error) string {
+	var blocked *taskservice.BlockedError
+	if errors.As(err, &blocked) {
+		return blocked.Error()
+	}
+	var transition *taskservice.TransitionError
+	if errors.As(err, &transition) {
+		return transition.Error()
+	}
+	return err.Error()
+}
+
+func errorMetadata(err error) map[string]string {
+	var blocked *taskservice.BlockedError
+	if errors.As(err, &blocked) {
+		ids := make([]string, len(blocked.BlockerIds))
+		for i, id := range blocked.BlockerIds {
+			ids[i] = strconv.FormatUint(id, 10)
+		}
+		return map[string]string{"blocker_ids": strings.Join(ids, ",")}
+	}
+	var transition *taskservice.TransitionError
+	if errors.As(err, &transition) {
+		allowed := make([]string, len(transition.Allowed))
+		for i, s := range transition.Allowed {
+			allowed[i] = s.String()
+		}
+		return map[string]string{
+			"from":    transition.From.String(),
+			"to":      transition.To.String(),
+			"allowed": strings.Join(allowed, ","),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package TaskService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/grpc/converter"
+	"github.com/Citadelas/task/internal/grpc/validation"
+	"github.com/Citadelas/task/internal/grpc/validation/requests"
+	"github.com/Citadelas/task/internal/lib/requestid"
+	taskservice "github.com/Citadelas/task/internal/services/task"
+	"github.com/Citadelas/task/internal/storage"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// details returns the ErrorInfo and BadRequest attached to a status.
+func details(st *status.Status) (*errdetails.ErrorInfo, *errdetails.BadRequest) {
+	var info *errdetails.ErrorInfo
+	var badRequest *errdetails.BadRequest
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			info = d
+		case *errdetails.BadRequest:
+			badRequest = d
+		}
+	}
+	return info, badRequest
+}
+
+func TestTranslateErrorDetails(t *testing.T) {
+	tests := []struct {
+		err    error
+		code   codes.Code
+		reason string
+		// field is set for errors reported as a BadRequest field violation.
+		field string
+	}{
+		{err: storage.ErrTaskNotFound, code: codes.NotFound, reason: reasonTaskNotFound},
+		{err: taskservice.ErrVersionConflict, code: codes.Aborted, reason: reasonVersionConflict},
+		{err: storage.ErrInputTooLong, code: codes.InvalidArgument, reason: reasonInputTooLong},
+		{err: &taskservice.BlockedError{BlockerIds: []uint64{4, 5}}, code: codes.FailedPrecondition,
+			reason: reasonTaskBlocked},
+		{err: &taskservice.TransitionError{From: models.StatusDone, To: models.StatusTodo},
+			code: codes.FailedPrecondition, reason: reasonInvalidTransition},
+		{err: taskservice.ErrInvalidMask, code: codes.InvalidArgument, reason: reasonInvalidMask,
+			field: updateMaskHeader},
+		{err: errInvalidUpdateMask, code: codes.InvalidArgument, reason: reasonInvalidMask, field: updateMaskHeader},
+		{err: errInvalidIfMatch, code: codes.InvalidArgument, reason: reasonInvalidIfMatch, field: ifMatchHeader},
+		{err: models.ErrUnknownPriority, code: codes.InvalidArgument, reason: reasonUnknownPriority,
+			field: "priority"},
+		{err: models.ErrUnknownStatus, code: codes.InvalidArgument, reason: reasonUnknownStatus, field: "status"},
+		{err: converter.ErrInvalidDueDate, code: codes.InvalidArgument, reason: reasonInvalidDueDate,
+			field: "due_date"},
+		{err: taskservice.ErrInvalidPageToken, code: codes.InvalidArgument, reason: reasonInvalidPageToken,
+			field: "page_token"},
+		{err: taskservice.ErrInvalidTag, code: codes.InvalidArgument, reason: reasonInvalidTag, field: "tags"},
+		{err: taskservice.ErrTagExists, code: codes.AlreadyExists, reason: reasonTagExists},
+		{err: taskservice.ErrWrongTagId, code: codes.NotFound, reason: reasonTagNotFound},
+		{err: taskservice.ErrWrongBlockerId, code: codes.NotFound, reason: reasonBlockerNotFound},
+		{err: taskservice.ErrDependencyCycle, code: codes.FailedPrecondition, reason: reasonDependencyCycle},
+		{err: taskservice.ErrWrongParentId, code: codes.NotFound, reason: reasonParentNotFound},
+		{err: taskservice.ErrHierarchyCycle, code: codes.FailedPrecondition, reason: reasonHierarchyCycle},
+		{err: taskservice.ErrWrongSeriesId, code: codes.NotFound, reason: reasonSeriesNotFound},
+		{err: taskservice.ErrInvalidRecurrence, code: codes.InvalidArgument, reason: reasonInvalidRecurrence,
+			field: "rule"},
+	}
+	log := slog.New(slog.DiscardHandler)
+	for _, tt := range tests {
+		t.Run(tt.reason, func(t *testing.T) {
+			// Services wrap their errors with the operation name.
+			err := TranslateError(context.Background(), log, fmt.Errorf("task.Op: %w", tt.err))
+			st := status.Convert(err)
+			if st.Code() != tt.code {
+				t.Fatalf("code = %v, want %v", st.Code(), tt.code)
+			}
+			info, badRequest := details(st)
+			if tt.field != "" {
+				violations := badRequest.GetFieldViolations()
+				if info != nil || len(violations) != 1 || violations[0].GetField() != tt.field ||
+					violations[0].GetReason() != tt.reason {
+					t.Fatalf("details = %v, %v, want a %s violation of %s", info, badRequest, tt.reason, tt.field)
+				}
+				return
+			}
+			if badRequest != nil || info.GetReason() != tt.reason || info.GetDomain() != errorDomain {
+				t.Fatalf("details = %v, %v, want reason %s in %s", info, badRequest, tt.reason, errorDomain)
+			}
+		})
+	}
+}
+
+func TestTranslateErrorMetadata(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	blocked := TranslateError(context.Background(), log, &taskservice.BlockedError{BlockerIds: []uint64{4, 5}})
+	if info, _ := details(status.Convert(blocked)); info.GetMetadata()["blocker_ids"] != "4,5" {
+		t.Errorf("blocked metadata = %v", info.GetMetadata())
+	}
+	transition := TranslateError(context.Background(), log, &taskservice.TransitionError{
+		From:    models.StatusDone,
+		To:      models.StatusTodo,
+		Allowed: []models.Status{models.StatusInProgress},
+	})
+	info, _ := details(status.Convert(transition))
+	if md := info.GetMetadata(); md["from"] != "DONE" || md["to"] != "TODO" || md["allowed"] != "IN_PROGRESS" {
+		t.Errorf("transition metadata = %v", md)
+	}
+}
+
+func TestValidationFieldViolations(t *testing.T) {
+	err := validation.ValidateStruct(context.Background(), requests.UpdateStatusRequest{ID: 0, UID: 7,
+		Status: "LATER"})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("code = %v, want InvalidArgument", st.Code())
+	}
+	_, badRequest := details(st)
+	got := make(map[string]string)
+	for _, v := range badRequest.GetFieldViolations() {
+		got[v.GetField()] = v.GetReason()
+	}
+	if len(got) != 2 || got["id"] != "REQUIRED" || got["status"] != "TASK_STATUS" {
+		t.Fatalf("field violations = %v", got)
+	}
+}
+
+func TestTranslateErrorCodes(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	passthrough := status.Error(codes.ResourceExhausted, "slow down")
+	tests := map[string]struct {
+		err  error
+		code codes.Code
+	}{
+		"missing task":      {err: fmt.Errorf("task.GetTask: %w", taskservice.ErrWrongId), code: codes.NotFound},
+		"status":            {err: passthrough, code: codes.ResourceExhausted},
+		"canceled":          {err: fmt.Errorf("task.GetTask: %w", context.Canceled), code: codes.Canceled},
+		"deadline exceeded": {err: context.DeadlineExceeded, code: codes.DeadlineExceeded},
+	}
+	for name, tt := range tests {
+		if got := status.Code(TranslateError(context.Background(), log, tt.err)); got != tt.code {
+			t.Errorf("%s: code = %v, want %v", name, got, tt.code)
+		}
+	}
+	if err := TranslateError(context.Background(), log, passthrough); err != passthrough {
+		t.Errorf("status changed to %v", err)
+	}
+}
+
+func TestTranslateErrorHidesInternalErrors(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	ctx := requestid.NewContext(context.Background(), "req-42")
+	err := TranslateError(ctx, log, errors.New("pq: password authentication failed for user \"task\""))
+	st := status.Convert(err)
+	if st.Code() != codes.Internal {
+		t.Fatalf("code = %v, want Internal", st.Code())
+	}
+	if strings.Contains(st.Message(), "password") || !strings.Contains(st.Message(), "req-42") {
+		t.Fatalf("message = %q, want only the correlation id", st.Message())
+	}
+	info, _ := details(st)
+	if info.GetReason() != reasonInternal || info.GetMetadata()["correlation_id"] != "req-42" {
+		t.Fatalf("error info = %v", info)
+	}
+}
@@ -0,0 +1,59 @@
+package TaskService
+
+import (
+	"context"
+	"errors"
+	"github.com/Citadelas/task/internal/domain/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"strconv"
+	"strings"
+)
+
+// Task versions travel as HTTP-style entity tags in metadata: responses
+// carry the current version in "etag" and writes may send it back in
+// "if-match" to only apply when nobody changed the task in between.
+const (
+	etagHeader    = "etag"
+	ifMatchHeader = "if-match"
+)
+
+var errInvalidIfMatch = errors.New("if-match must be a task version such as \"3\"")
+
+// expectedVersionFromContext returns the version named in if-match, or
+// zero when the header is absent or "*".
+func expectedVersionFromContext(ctx context.Context) (uint64, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return 0, nil
+	}
+	values := md.Get(ifMatchHeader)
+	if len(values) == 0 {
+		return 0, nil
+	}
+	return VersionFromIfMatch(values[0])
+}
+
+// VersionFromIfMatch parses an if-match value; "" and "*" match any
+// version and yield zero.
+func VersionFromIfMatch(tag string) (uint64, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" || tag == "*" {
+		return 0, nil
+	}
+	tag = strings.Trim(strings.TrimPrefix(tag, "W/"), `"`)
+	version, err := strconv.ParseUint(tag, 10, 64)
+	if err != nil || version == 0 {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
+
+// ETag formats the version of a task as an entity tag.
+func ETag(task *models.Task) string {
+	return strconv.Quote(strconv.FormatUint(task.Version, 10))
+}
+
+func setETag(ctx context.Context, task *models.Task) {
+	_ = grpc.SetHeader(ctx, metadata.Pairs(etagHeader, ETag(task)))
+}
@@ -0,0 +1,17 @@
+package TaskService
+
+import (
+	"context"
+	"github.com/Citadelas/task/internal/lib/auth"
+)
+
+// UserId returns the user a call acts for: the authenticated one when the
+// call carries a token, otherwise the user_id of the request (trusted
+// mode). With a token the request's user_id is ignored, so a caller can
+// only ever act for the user its token names.
+func UserId(ctx context.Context, requested uint64) uint64 {
+	if uid, ok := auth.UserIdFromContext(ctx); ok {
+		return uid
+	}
+	return requested
+}
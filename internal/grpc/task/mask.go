@@ -0,0 +1,48 @@
+package TaskService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	taskv1 "github.com/Citadelas/protos/golang/task"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"strings"
+)
+
+// updateMaskHeader carries a google.protobuf.FieldMask for UpdateTask as a
+// comma-separated list of field paths until the request message has its
+// own update_mask field.
+const updateMaskHeader = "x-update-mask"
+
+var errInvalidUpdateMask = errors.New("invalid update mask")
+
+func updateMaskFromContext(ctx context.Context) ([]string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+	var paths []string
+	for _, value := range md.Get(updateMaskHeader) {
+		for _, path := range strings.Split(value, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				paths = append(paths, path)
+			}
+		}
+	}
+	return ParseUpdateMask(paths)
+}
+
+// ParseUpdateMask checks field paths against UpdateTaskRequest and returns
+// them normalized; no paths yield a nil mask.
+func ParseUpdateMask(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	mask, err := fieldmaskpb.New(&taskv1.UpdateTaskRequest{}, paths...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidUpdateMask, err)
+	}
+	mask.Normalize()
+	return mask.GetPaths(), nil
+}
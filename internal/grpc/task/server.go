@@ -2,30 +2,27 @@ package TaskService
 
 import (
 	"context"
-	"errors"
 	taskv1 "github.com/Citadelas/protos/golang/task"
 	"github.com/Citadelas/task/internal/domain/models"
 	"github.com/Citadelas/task/internal/grpc/converter"
 	"github.com/Citadelas/task/internal/grpc/validation"
 	"github.com/Citadelas/task/internal/grpc/validation/requests"
-	taskservice "github.com/Citadelas/task/internal/services/task"
-	"github.com/Citadelas/task/internal/storage"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"time"
 )
 
 type Task interface {
 	CreateTask(ctx context.Context, uid uint64,
-		title, description, priority string) (*models.Task, error)
+		title, description string, priority models.Priority, dueDate *time.Time, tags []string) (*models.Task, error)
 
 	GetTask(ctx context.Context, id, uid uint64) (*models.Task, error)
-	UpdateTask(ctx context.Context, id, uid uint64, title, description string,
-		priority string) (*models.Task, error)
+	UpdateTask(ctx context.Context, id, uid, version uint64, update models.TaskUpdate) (*models.Task, error)
 
-	DeleteTask(ctx context.Context, id, uid uint64) error
-	UpdateStatus(ctx context.Context, id, uid uint64, status string) (*models.Task, error)
+	DeleteTask(ctx context.Context, id, uid, version uint64) error
+	UpdateStatus(ctx context.Context, id, uid, version uint64, status models.Status) (*models.Task, error)
+	ListTasks(ctx context.Context, query models.ListTasksQuery) (*models.TaskPage, error)
+	NextStatuses(status models.Status) []models.Status
 }
 
 type serverAPI struct {
@@ -43,124 +40,153 @@ func Register(gRPC *grpc.Server, task Task) {
 
 func (s *serverAPI) CreateTask(
 	ctx context.Context, req *taskv1.CreateTaskRequest) (*taskv1.CreateTaskResponse, error) {
+	uid := UserId(ctx, req.GetUserId())
+	newTask, err := converter.CreateFromProto(req)
+	if err != nil {
+		return nil, InvalidArgument(err)
+	}
+	newTask.UserId = uid
 	validationReq := requests.CreateTaskRequest{
-		UID:         req.GetUserId(),
-		Title:       req.GetTitle(),
-		Description: req.GetDescription(),
-		Priority:    req.GetPriority().String(),
+		UID:         newTask.UserId,
+		Title:       newTask.Title,
+		Description: newTask.Description,
+		Priority:    newTask.Priority.String(),
+		DueDate:     newTask.DueDate,
 	}
-	if err := validation.ValidateStruct(validationReq); err != nil {
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
 		return nil, err
 	}
-	priority := req.GetPriority().String()
-	task, err := s.task.CreateTask(ctx, req.GetUserId(), req.GetTitle(), req.GetDescription(), priority)
+	// CreateTaskRequest carries no tags yet.
+	task, err := s.task.CreateTask(ctx, newTask.UserId, newTask.Title, newTask.Description, newTask.Priority,
+		newTask.DueDate, nil)
 	if err != nil {
-		if errors.Is(err, storage.ErrInputTooLong) {
-			return nil, status.Error(codes.InvalidArgument, storage.ErrInputTooLong.Error())
-		}
-		return nil, status.Error(codes.Internal, "internal error")
+		return nil, err
 	}
 	res, err := s.adapter.ToProto(task)
 	if err != nil {
-		return nil, status.Error(codes.Internal, "internal error")
+		return nil, err
 	}
-	return &taskv1.CreateTaskResponse{Task: res}, err
+	setETag(ctx, task)
+	setNextStatuses(ctx, s.task.NextStatuses(task.Status))
+	return &taskv1.CreateTaskResponse{Task: res}, nil
 }
 
 func (s *serverAPI) GetTask(
 	ctx context.Context, req *taskv1.GetTaskRequest) (*taskv1.GetTaskResponse, error) {
-
-	validationReq := requests.GetTaskRequest{ID: req.GetId(), UID: req.GetUserId()}
-	if err := validation.ValidateStruct(validationReq); err != nil {
+	uid := UserId(ctx, req.GetUserId())
+	validationReq := requests.GetTaskRequest{ID: req.GetId(), UID: uid}
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
 		return nil, err
 	}
 
-	task, err := s.task.GetTask(ctx, req.GetId(), req.GetUserId())
+	task, err := s.task.GetTask(ctx, req.GetId(), uid)
 	if err != nil {
-		if errors.Is(err, taskservice.ErrWrongId) {
-			return nil, status.Error(codes.InvalidArgument, "task not found")
-		}
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
 	}
 	res, err := s.adapter.ToProto(task)
 	if err != nil {
-		return nil, status.Error(codes.Internal, "internal error")
+		return nil, err
 	}
+	setETag(ctx, task)
+	setNextStatuses(ctx, s.task.NextStatuses(task.Status))
 	return &taskv1.GetTaskResponse{Task: res}, nil
 }
 
 func (s *serverAPI) UpdateTask(
 	ctx context.Context, req *taskv1.UpdateTaskRequest) (*taskv1.UpdateTaskResponse, error) {
+	uid := UserId(ctx, req.GetUserId())
+	mask, err := updateMaskFromContext(ctx)
+	if err != nil {
+		return nil, InvalidArgument(err)
+	}
+	update, err := converter.UpdateFromProto(req, mask)
+	if err != nil {
+		return nil, InvalidArgument(err)
+	}
 	validationReq := requests.UpdateTaskRequest{
 		ID:          req.GetId(),
-		UID:         req.GetUserId(),
-		Title:       req.GetTitle(),
-		Description: req.GetDescription(),
-		Priority:    req.GetPriority().String(),
-	}
-	if err := validation.ValidateStruct(validationReq); err != nil {
+		UID:         uid,
+		UpdateMask:  update.Mask,
+		Title:       update.Title,
+		Description: update.Description,
+		Priority:    update.Priority.String(),
+		DueDate:     update.DueDate,
+	}
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
 		return nil, err
 	}
 
-	task, err := s.task.UpdateTask(ctx, req.GetId(), req.GetUserId(), req.GetTitle(), req.GetDescription(), req.GetPriority().String())
+	version, err := expectedVersionFromContext(ctx)
 	if err != nil {
-		if errors.Is(err, taskservice.ErrWrongId) {
-			return nil, status.Error(codes.InvalidArgument, "task not found")
-		}
-		if errors.Is(err, storage.ErrInputTooLong) {
-			return nil, status.Error(codes.InvalidArgument, storage.ErrInputTooLong.Error())
-		}
-		return nil, status.Error(codes.Internal, "internal error")
+		return nil, InvalidArgument(err)
+	}
+
+	task, err := s.task.UpdateTask(ctx, req.GetId(), uid, version, update)
+	if err != nil {
+		return nil, err
 	}
 	res, err := s.adapter.ToProto(task)
 	if err != nil {
-		return nil, status.Error(codes.Internal, "internal error")
+		return nil, err
 	}
+	setETag(ctx, task)
+	setNextStatuses(ctx, s.task.NextStatuses(task.Status))
 	return &taskv1.UpdateTaskResponse{Task: res}, nil
 }
 
 func (s *serverAPI) DeleteTask(
 	ctx context.Context, req *taskv1.DeleteTaskRequest) (*emptypb.Empty, error) {
-
+	uid := UserId(ctx, req.GetUserId())
 	validationReq := requests.DeleteTaskRequest{
 		ID:  req.GetId(),
-		UID: req.GetUserId(),
+		UID: uid,
 	}
-	if err := validation.ValidateStruct(validationReq); err != nil {
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
 		return nil, err
 	}
 
-	err := s.task.DeleteTask(ctx, req.GetId(), req.GetUserId())
+	version, err := expectedVersionFromContext(ctx)
 	if err != nil {
-		if errors.Is(err, taskservice.ErrWrongId) {
-			return nil, status.Error(codes.InvalidArgument, "task not found")
-		}
-		return nil, status.Error(codes.Internal, "internal error")
+		return nil, InvalidArgument(err)
+	}
+
+	err = s.task.DeleteTask(ctx, req.GetId(), uid, version)
+	if err != nil {
+		return nil, err
 	}
 	return &emptypb.Empty{}, nil
 }
 
 func (s *serverAPI) UpdateStatus(
 	ctx context.Context, req *taskv1.UpdateStatusRequest) (*taskv1.UpdateStatusResponse, error) {
+	uid := UserId(ctx, req.GetUserId())
+	newStatus, err := converter.StatusFromProto(req.GetStatus())
+	if err != nil {
+		return nil, InvalidArgument(err)
+	}
 	validationReq := requests.UpdateStatusRequest{
 		ID:     req.GetId(),
-		Status: req.GetStatus().String(),
-		UID:    req.GetUserId(),
+		Status: newStatus.String(),
+		UID:    uid,
 	}
-	if err := validation.ValidateStruct(validationReq); err != nil {
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
 		return nil, err
 	}
 
-	task, err := s.task.UpdateStatus(ctx, req.GetId(), req.GetUserId(), req.GetStatus().String())
+	version, err := expectedVersionFromContext(ctx)
 	if err != nil {
-		if errors.Is(err, taskservice.ErrWrongId) {
-			return nil, status.Error(codes.InvalidArgument, "task not found")
-		}
-		return nil, status.Error(codes.Internal, "internal error")
+		return nil, InvalidArgument(err)
+	}
+
+	task, err := s.task.UpdateStatus(ctx, req.GetId(), uid, version, newStatus)
+	if err != nil {
+		return nil, err
 	}
 	res, err := s.adapter.ToProto(task)
 	if err != nil {
-		return nil, status.Error(codes.Internal, "internal error")
+		return nil, err
 	}
+	setETag(ctx, task)
+	setNextStatuses(ctx, s.task.NextStatuses(task.Status))
 	return &taskv1.UpdateStatusResponse{Task: res}, nil
 }
@@ -0,0 +1,26 @@
+package TaskService
+
+import (
+	"context"
+	"github.com/Citadelas/task/internal/domain/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"strings"
+)
+
+// nextStatusesHeader lists the statuses the returned task may move to next,
+// comma separated, so clients can offer only valid status changes.
+const nextStatusesHeader = "x-next-statuses"
+
+// JoinStatuses formats statuses as the x-next-statuses header value.
+func JoinStatuses(statuses []models.Status) string {
+	names := make([]string, len(statuses))
+	for i, status := range statuses {
+		names[i] = status.String()
+	}
+	return strings.Join(names, ",")
+}
+
+func setNextStatuses(ctx context.Context, statuses []models.Status) {
+	_ = grpc.SetHeader(ctx, metadata.Pairs(nextStatusesHeader, JoinStatuses(statuses)))
+}
@@ -0,0 +1,89 @@
+package TaskService
+
+import (
+	"context"
+	taskv1 "github.com/Citadelas/protos/golang/task"
+	"github.com/Citadelas/task/internal/domain/models"
+	taskservice "github.com/Citadelas/task/internal/services/task"
+	"github.com/Citadelas/task/internal/storage/memory"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"net"
+	"testing"
+)
+
+// newTestClient serves a memory-backed service with the given workflow the
+// way the app does and returns a client for it.
+func newTestClient(t *testing.T, workflow *models.Workflow) taskv1.TaskServiceClient {
+	t.Helper()
+	log := slog.New(slog.DiscardHandler)
+	s := memory.New()
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(UnaryErrorInterceptor(log)))
+	Register(server, taskservice.New(log, s, s, s, s, s, s, s, s, s, s, s, s, workflow))
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = server.Serve(l) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return taskv1.NewTaskServiceClient(conn)
+}
+
+func TestUpdateStatusFollowsWorkflow(t *testing.T) {
+	workflow, err := models.NewWorkflow(map[string][]string{
+		"todo":        {"in_progress"},
+		"in_progress": {"todo", "done"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newTestClient(t, workflow)
+	ctx := context.Background()
+
+	var header metadata.MD
+	created, err := client.CreateTask(ctx, &taskv1.CreateTaskRequest{UserId: 1, Title: "title",
+		Description: "description"}, grpc.Header(&header))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get(nextStatusesHeader); len(got) != 1 || got[0] != "IN_PROGRESS" {
+		t.Fatalf("next statuses after create = %v, want [IN_PROGRESS]", got)
+	}
+	id := created.GetTask().GetId()
+
+	_, err = client.UpdateStatus(ctx, &taskv1.UpdateStatusRequest{Id: id, UserId: 1, Status: taskv1.TaskStatus_DONE})
+	st := status.Convert(err)
+	if st.Code() != codes.FailedPrecondition {
+		t.Fatalf("TODO -> DONE = %v, want FailedPrecondition", err)
+	}
+	var info *errdetails.ErrorInfo
+	for _, detail := range st.Details() {
+		if d, ok := detail.(*errdetails.ErrorInfo); ok {
+			info = d
+		}
+	}
+	if info.GetReason() != reasonInvalidTransition || info.GetMetadata()["allowed"] != "IN_PROGRESS" {
+		t.Fatalf("error info = %v", info)
+	}
+
+	header = nil
+	_, err = client.UpdateStatus(ctx, &taskv1.UpdateStatusRequest{Id: id, UserId: 1,
+		Status: taskv1.TaskStatus_IN_PROGRESS}, grpc.Header(&header))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get(nextStatusesHeader); len(got) != 1 || got[0] != "TODO,DONE" {
+		t.Fatalf("next statuses after start = %v, want [TODO,DONE]", got)
+	}
+}
@@ -1,32 +1,110 @@
 package requests
 
+import "time"
+
 type CreateTaskRequest struct {
-	UID         uint64 `validate:"required,gt=0"`
-	Title       string `validate:"required,min=1,max=200"`
-	Description string `validate:"required,min=1,max=1000"`
-	Priority    string `validate:"required,oneof=LOW MEDIUM HIGH"`
+	UID         uint64     `json:"user_id" validate:"required,gt=0"`
+	Title       string     `json:"title" validate:"required,min=1,max=200"`
+	Description string     `json:"description" validate:"required,min=1,max=1000"`
+	Priority    string     `json:"priority" validate:"required,task_priority"`
+	DueDate     *time.Time `json:"due_date" validate:"omitempty,due_date"`
 }
 
 type GetTaskRequest struct {
-	ID  uint64 `validate:"required,gt=0"`
-	UID uint64 `validate:"required,gt=0"`
+	ID  uint64 `json:"id" validate:"required,gt=0"`
+	UID uint64 `json:"user_id" validate:"required,gt=0"`
 }
 
 type UpdateTaskRequest struct {
-	ID          uint64 `validate:"required,gt=0"`
-	UID         uint64 `validate:"required,gt=0"`
-	Title       string `validate:"omitempty,min=1,max=200"`
-	Description string `validate:"omitempty,min=1,max=1000"`
-	Priority    string `validate:"omitempty,oneof=LOW MEDIUM HIGH"`
+	ID          uint64     `json:"id" validate:"required,gt=0"`
+	UID         uint64     `json:"user_id" validate:"required,gt=0"`
+	UpdateMask  []string   `json:"update_mask" validate:"required,min=1,dive,oneof=title description priority due_date"`
+	Title       string     `json:"title" validate:"omitempty,min=1,max=200"`
+	Description string     `json:"description" validate:"omitempty,max=1000"`
+	Priority    string     `json:"priority" validate:"omitempty,task_priority"`
+	DueDate     *time.Time `json:"due_date" validate:"omitempty,due_date"`
 }
 
 type DeleteTaskRequest struct {
-	ID  uint64 `validate:"required,gt=0"`
-	UID uint64 `validate:"required,gt=0"`
+	ID  uint64 `json:"id" validate:"required,gt=0"`
+	UID uint64 `json:"user_id" validate:"required,gt=0"`
 }
 
 type UpdateStatusRequest struct {
-	ID     uint64 `validate:"required,gt=0"`
-	UID    uint64 `validate:"required,gt=0"`
-	Status string `validate:"required,task_status"`
+	ID     uint64 `json:"id" validate:"required,gt=0"`
+	UID    uint64 `json:"user_id" validate:"required,gt=0"`
+	Status string `json:"status" validate:"required,task_status"`
+}
+
+type ListTasksRequest struct {
+	UID        uint64   `json:"user_id" validate:"required,gt=0"`
+	PageSize   int      `json:"page_size" validate:"gte=0,lte=500"`
+	SortBy     string   `json:"sort_by" validate:"omitempty,oneof=created_at due_date priority"`
+	Statuses   []string `json:"status" validate:"dive,task_status"`
+	Priorities []string `json:"priority" validate:"dive,task_priority"`
+}
+
+type WatchTasksRequest struct {
+	UID uint64 `json:"user_id" validate:"required,gt=0"`
+}
+
+type ListTrashRequest struct {
+	UID      uint64 `json:"user_id" validate:"required,gt=0"`
+	PageSize int    `json:"page_size" validate:"gte=0,lte=500"`
+}
+
+type TaskHistoryRequest struct {
+	ID       uint64 `json:"id" validate:"required,gt=0"`
+	UID      uint64 `json:"user_id" validate:"required,gt=0"`
+	PageSize int    `json:"page_size" validate:"gte=0,lte=500"`
+}
+
+type MoveTaskRequest struct {
+	ID       uint64  `json:"id" validate:"required,gt=0"`
+	UID      uint64  `json:"user_id" validate:"required,gt=0"`
+	ParentID *uint64 `json:"parent_id" validate:"omitempty,gt=0"`
+}
+
+type BlockerRequest struct {
+	ID        uint64 `json:"id" validate:"required,gt=0"`
+	UID       uint64 `json:"user_id" validate:"required,gt=0"`
+	BlockerID uint64 `json:"blocker_id" validate:"required,gt=0"`
+}
+
+type ListTagsRequest struct {
+	UID uint64 `json:"user_id" validate:"required,gt=0"`
+}
+
+type CreateTagRequest struct {
+	UID  uint64 `json:"user_id" validate:"required,gt=0"`
+	Name string `json:"name" validate:"required"`
+}
+
+type RenameTagRequest struct {
+	ID   uint64 `json:"id" validate:"required,gt=0"`
+	UID  uint64 `json:"user_id" validate:"required,gt=0"`
+	Name string `json:"name" validate:"required"`
+}
+
+type DeleteTagRequest struct {
+	ID  uint64 `json:"id" validate:"required,gt=0"`
+	UID uint64 `json:"user_id" validate:"required,gt=0"`
+}
+
+type RecurrenceRequest struct {
+	ID   uint64 `json:"id" validate:"required,gt=0"`
+	UID  uint64 `json:"user_id" validate:"required,gt=0"`
+	Rule string `json:"rule" validate:"required,max=500"`
+}
+
+type SeriesRequest struct {
+	ID  uint64 `json:"id" validate:"required,gt=0"`
+	UID uint64 `json:"user_id" validate:"required,gt=0"`
+}
+
+type TaskTagsRequest struct {
+	ID     uint64   `json:"id" validate:"required,gt=0"`
+	UID    uint64   `json:"user_id" validate:"required,gt=0"`
+	Add    []string `json:"add" validate:"required_without=Remove"`
+	Remove []string `json:"remove" validate:"required_without=Add"`
 }
@@ -1,29 +1,50 @@
 package validation
 
 import (
-	taskv1 "github.com/Citadelas/protos/golang/task"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/grpc/validation/requests"
 	"github.com/go-playground/validator/v10"
+	"slices"
+	"time"
 )
 
 func registerCustomValidators(v *validator.Validate) {
 	v.RegisterValidation("task_priority", validateTaskPriority)
 
 	v.RegisterValidation("task_status", validateTaskStatus)
+
+	v.RegisterValidation("due_date", validateDueDate)
+
+	v.RegisterStructValidation(validateUpdateTaskRequest, requests.UpdateTaskRequest{})
 }
 
 func validateTaskPriority(fl validator.FieldLevel) bool {
-	priority := fl.Field().String()
-	_, exists := taskv1.TaskPriority_value[priority]
-	return exists
+	_, err := models.ParsePriority(fl.Field().String())
+	return err == nil
 }
 
 func validateTaskStatus(fl validator.FieldLevel) bool {
-	status := fl.Field().String()
+	_, err := models.ParseStatus(fl.Field().String())
+	return err == nil
+}
+
+var minDueDate = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
 
-	_, exists := taskv1.TaskStatus_value[status]
-	if status == "TASK_STATUS_UNSPECIFIED" || status == "0" {
+const maxDueDateAhead = 100 * 365 * 24 * time.Hour
+
+func validateDueDate(fl validator.FieldLevel) bool {
+	dueDate, ok := fl.Field().Interface().(time.Time)
+	if !ok {
 		return false
 	}
+	return !dueDate.Before(minDueDate) && dueDate.Before(time.Now().Add(maxDueDateAhead))
+}
 
-	return exists
+// validateUpdateTaskRequest requires a title whenever the mask names it,
+// since a task cannot be left without one.
+func validateUpdateTaskRequest(sl validator.StructLevel) {
+	req := sl.Current().Interface().(requests.UpdateTaskRequest)
+	if slices.Contains(req.UpdateMask, models.FieldTitle) && req.Title == "" {
+		sl.ReportError(req.Title, "title", "Title", "required", "")
+	}
 }
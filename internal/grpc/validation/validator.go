@@ -1,10 +1,16 @@
 package validation
 
 import (
+	"context"
 	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/lib/tracing"
 	"github.com/go-playground/validator/v10"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"reflect"
+	"strings"
 )
 
 var (
@@ -14,12 +20,15 @@ var (
 func GetValidator() *validator.Validate {
 	if validate == nil {
 		validate = validator.New()
+		validate.RegisterTagNameFunc(fieldName)
 		registerCustomValidators(validate)
 	}
 	return validate
 }
 
-func ValidateStruct(req interface{}) error {
+func ValidateStruct(ctx context.Context, req interface{}) (err error) {
+	_, span := tracing.Start(ctx, "validation.ValidateStruct")
+	defer tracing.End(span, &err)
 	if err := GetValidator().Struct(req); err != nil {
 		if validationErrors, ok := err.(validator.ValidationErrors); ok {
 			return formatValidationError(validationErrors)
@@ -29,23 +38,79 @@ func ValidateStruct(req interface{}) error {
 	return nil
 }
 
+// fieldName reports fields by their proto name, taken from the json tag, so
+// field violations point at the request field the client actually sent.
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
 func formatValidationError(errs validator.ValidationErrors) error {
 	var messages []string
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(errs))
 	for _, err := range errs {
+		start := len(messages)
 		switch err.Tag() {
 		case "required":
 			messages = append(messages, fmt.Sprintf("%s is required", err.Field()))
+		case "required_without":
+			messages = append(messages, fmt.Sprintf("%s is required when %s is empty", err.Field(),
+				strings.ToLower(err.Param())))
 		case "min":
 			messages = append(messages, fmt.Sprintf("%s must be at least %s characters long", err.Field(), err.Param()))
 		case "max":
 			messages = append(messages, fmt.Sprintf("%s must be at most %s characters long", err.Field(), err.Param()))
 		case "gt":
 			messages = append(messages, fmt.Sprintf("%s must be greater than %s", err.Field(), err.Param()))
+		case "due_date":
+			messages = append(messages, fmt.Sprintf("%s must be between 2000-01-01 and 100 years from now", err.Field()))
+		case "task_priority":
+			messages = append(messages, fmt.Sprintf("%s must be one of: %s", err.Field(), joinNames(models.Priorities)))
+		case "task_status":
+			messages = append(messages, fmt.Sprintf("%s must be one of: %s", err.Field(), joinNames(models.Statuses)))
 		case "oneof":
 			messages = append(messages, fmt.Sprintf("%s must be one of: %s", err.Field(), err.Param()))
 		default:
 			messages = append(messages, fmt.Sprintf("%s failed validation", err.Field()))
 		}
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       err.Field(),
+			Reason:      strings.ToUpper(err.Tag()),
+			Description: messages[start],
+		})
+	}
+	st := status.Newf(codes.InvalidArgument, "validation errors: %v", messages)
+	return withDetails(st, &errdetails.BadRequest{FieldViolations: violations})
+}
+
+// FieldError reports a single invalid request field that was rejected
+// before validation, e.g. while decoding enums or metadata.
+func FieldError(field, reason string, err error) error {
+	st := status.New(codes.InvalidArgument, err.Error())
+	return withDetails(st, &errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+			Field:       field,
+			Reason:      reason,
+			Description: err.Error(),
+		}},
+	})
+}
+
+func joinNames[T ~string](values []T) string {
+	names := make([]string, len(values))
+	for i, v := range values {
+		names[i] = string(v)
+	}
+	return strings.Join(names, " ")
+}
+
+func withDetails(st *status.Status, details *errdetails.BadRequest) error {
+	detailed, err := st.WithDetails(details)
+	if err != nil {
+		return st.Err()
 	}
-	return status.Errorf(codes.InvalidArgument, "validation errors: %v", messages)
+	return detailed.Err()
 }
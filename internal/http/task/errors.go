@@ -0,0 +1,62 @@
+package taskhttp
+
+import (
+	"fmt"
+	taskgrpc "github.com/Citadelas/task/internal/grpc/task"
+	"github.com/Citadelas/task/internal/grpc/validation"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"net/http"
+)
+
+// httpStatuses follows the mapping of google/rpc/code.proto.
+var httpStatuses = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           499,
+	codes.Unknown:            http.StatusInternalServerError,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.Aborted:            http.StatusConflict,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Internal:           http.StatusInternalServerError,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.DataLoss:           http.StatusInternalServerError,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+}
+
+// HTTPStatus returns the HTTP status code matching a gRPC code.
+func HTTPStatus(code codes.Code) int {
+	if res, ok := httpStatuses[code]; ok {
+		return res
+	}
+	return http.StatusInternalServerError
+}
+
+// WriteStatus writes st as the JSON form of google.rpc.Status, details
+// included, so HTTP clients see the same code, message and details as
+// gRPC clients do.
+func WriteStatus(w http.ResponseWriter, st *status.Status) {
+	body, err := statusMarshalOptions.Marshal(st.Proto())
+	if err != nil {
+		body = []byte(`{"code":13,"message":"internal error"}`)
+	}
+	writeJSON(w, HTTPStatus(st.Code()), body)
+}
+
+// writeError translates err the way the gRPC error interceptor does.
+func (h *handler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	log := h.log.With(slog.String("method", r.Method), slog.String("path", r.URL.Path))
+	WriteStatus(w, status.Convert(taskgrpc.TranslateError(r.Context(), log, err)))
+}
+
+// invalidParam reports a path or query parameter that could not be parsed.
+func invalidParam(name string, err error) error {
+	return validation.FieldError(name, "INVALID", fmt.Errorf("%s %w", name, err))
+}
@@ -0,0 +1,370 @@
+package taskhttp
+
+import (
+	"context"
+	"errors"
+	taskv1 "github.com/Citadelas/protos/golang/task"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/grpc/converter"
+	taskgrpc "github.com/Citadelas/task/internal/grpc/task"
+	"github.com/Citadelas/task/internal/grpc/validation"
+	"github.com/Citadelas/task/internal/grpc/validation/requests"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type Task interface {
+	CreateTask(ctx context.Context, uid uint64,
+		title, description string, priority models.Priority, dueDate *time.Time, tags []string) (*models.Task, error)
+
+	GetTask(ctx context.Context, id, uid uint64) (*models.Task, error)
+	UpdateTask(ctx context.Context, id, uid, version uint64, update models.TaskUpdate) (*models.Task, error)
+
+	DeleteTask(ctx context.Context, id, uid, version uint64) error
+	UpdateStatus(ctx context.Context, id, uid, version uint64, status models.Status) (*models.Task, error)
+	ListTasks(ctx context.Context, query models.ListTasksQuery) (*models.TaskPage, error)
+	NextStatuses(status models.Status) []models.Status
+	AllowedStatuses(ctx context.Context, id, uid uint64) ([]models.Status, error)
+	WatchTasks(ctx context.Context, uid, after uint64, send func(*models.TaskEvent) error) error
+
+	ListTrash(ctx context.Context, uid uint64, pageSize int, pageToken string) (*models.TaskPage, error)
+	RestoreTask(ctx context.Context, id, uid uint64) (*models.Task, error)
+	PurgeTask(ctx context.Context, id, uid uint64) error
+	GetTaskHistory(ctx context.Context, id, uid uint64, pageSize int, pageToken string) (*models.HistoryPage, error)
+
+	CreateTag(ctx context.Context, uid uint64, name string) (*models.Tag, error)
+	ListTags(ctx context.Context, uid uint64) ([]*models.Tag, error)
+	RenameTag(ctx context.Context, id, uid uint64, name string) (*models.Tag, error)
+	DeleteTag(ctx context.Context, id, uid uint64) error
+
+	CreateSubtask(ctx context.Context, parentId, uid uint64, title, description string,
+		priority models.Priority, dueDate *time.Time, tags []string) (*models.Task, error)
+	MoveTask(ctx context.Context, id, uid, version uint64, parentId *uint64) (*models.Task, error)
+	GetSubtree(ctx context.Context, id, uid uint64) (*models.TaskTree, error)
+	AddBlocker(ctx context.Context, id, blockerId, uid uint64) error
+	RemoveBlocker(ctx context.Context, id, blockerId, uid uint64) error
+	ListBlockers(ctx context.Context, id, uid uint64) ([]*models.Task, error)
+
+	MakeRecurring(ctx context.Context, id, uid uint64, rule string) (*models.Task, error)
+	GetSeries(ctx context.Context, id, uid uint64) (*models.Series, error)
+	UpdateSeries(ctx context.Context, id, uid uint64, rule string) (*models.Series, error)
+	StopSeries(ctx context.Context, id, uid uint64) (*models.Series, error)
+}
+
+// Request and response headers mirroring the gRPC metadata of the same
+// name.
+const (
+	etagHeader         = "ETag"
+	ifMatchHeader      = "If-Match"
+	nextStatusesHeader = "X-Next-Statuses"
+)
+
+var errInvalidId = errors.New("must be a positive integer")
+
+type handler struct {
+	log      *slog.Logger
+	task     Task
+	adapter  *converter.TaskAdapter
+	shutdown <-chan struct{}
+}
+
+// handlerFunc is an endpoint that leaves error responses to handle.
+type handlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// Register mounts the task endpoints on mux. Requests and responses use
+// the protobuf JSON mapping of the gRPC messages, and calls are decoded,
+// validated and served exactly as their gRPC counterparts. Operations the
+// gRPC API does not have yet use plain JSON bodies, with tasks still in
+// the protobuf mapping. Event streams end when shutdown is closed.
+func Register(mux *http.ServeMux, log *slog.Logger, task Task, shutdown <-chan struct{}) {
+	h := &handler{
+		log:      log,
+		task:     task,
+		adapter:  converter.NewTaskAdapter(),
+		shutdown: shutdown,
+	}
+	mux.Handle("POST /v1/tasks", h.handle(h.createTask))
+	mux.Handle("GET /v1/tasks", h.handle(h.listTasks))
+	mux.Handle("GET /v1/tasks/watch", h.handle(h.watchTasks))
+	mux.Handle("GET /v1/tasks/{id}", h.handle(h.getTask))
+	mux.Handle("PATCH /v1/tasks/{id}", h.handle(h.updateTask))
+	mux.Handle("PUT /v1/tasks/{id}/status", h.handle(h.updateStatus))
+	mux.Handle("DELETE /v1/tasks/{id}", h.handle(h.deleteTask))
+	mux.Handle("GET /v1/tasks/{id}/statuses", h.handle(h.allowedStatuses))
+	mux.Handle("GET /v1/tasks/{id}/history", h.handle(h.taskHistory))
+	mux.Handle("PATCH /v1/tasks/{id}/tags", h.handle(h.updateTaskTags))
+	mux.Handle("POST /v1/tasks/{id}/subtasks", h.handle(h.createSubtask))
+	mux.Handle("GET /v1/tasks/{id}/subtree", h.handle(h.getSubtree))
+	mux.Handle("PUT /v1/tasks/{id}/parent", h.handle(h.moveTask))
+	mux.Handle("GET /v1/tasks/{id}/blockers", h.handle(h.listBlockers))
+	mux.Handle("PUT /v1/tasks/{id}/blockers/{blocker_id}", h.handle(h.addBlocker))
+	mux.Handle("DELETE /v1/tasks/{id}/blockers/{blocker_id}", h.handle(h.removeBlocker))
+	mux.Handle("PUT /v1/tasks/{id}/recurrence", h.handle(h.makeRecurring))
+
+	mux.Handle("GET /v1/trash", h.handle(h.listTrash))
+	mux.Handle("POST /v1/trash/{id}/restore", h.handle(h.restoreTask))
+	mux.Handle("DELETE /v1/trash/{id}", h.handle(h.purgeTask))
+
+	mux.Handle("GET /v1/tags", h.handle(h.listTags))
+	mux.Handle("POST /v1/tags", h.handle(h.createTag))
+	mux.Handle("PATCH /v1/tags/{id}", h.handle(h.renameTag))
+	mux.Handle("DELETE /v1/tags/{id}", h.handle(h.deleteTag))
+
+	mux.Handle("GET /v1/series/{id}", h.handle(h.getSeries))
+	mux.Handle("PATCH /v1/series/{id}", h.handle(h.updateSeries))
+	mux.Handle("POST /v1/series/{id}/stop", h.handle(h.stopSeries))
+}
+
+func (h *handler) handle(fn handlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := fn(w, r); err != nil {
+			h.writeError(w, r, err)
+		}
+	})
+}
+
+func (h *handler) createTask(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	var req taskv1.CreateTaskRequest
+	if _, err := decodeBody(r, &req); err != nil {
+		return taskgrpc.InvalidArgument(err)
+	}
+	uid := taskgrpc.UserId(ctx, req.GetUserId())
+	newTask, err := converter.CreateFromProto(&req)
+	if err != nil {
+		return taskgrpc.InvalidArgument(err)
+	}
+	newTask.UserId = uid
+	validationReq := requests.CreateTaskRequest{
+		UID:         newTask.UserId,
+		Title:       newTask.Title,
+		Description: newTask.Description,
+		Priority:    newTask.Priority.String(),
+		DueDate:     newTask.DueDate,
+	}
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
+		return err
+	}
+	task, err := h.task.CreateTask(ctx, newTask.UserId, newTask.Title, newTask.Description, newTask.Priority,
+		newTask.DueDate, nil)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Location", "/v1/tasks/"+strconv.FormatUint(task.Id, 10))
+	return h.writeTask(w, http.StatusCreated, task)
+}
+
+func (h *handler) getTask(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	id, requested, err := pathIds(r)
+	if err != nil {
+		return err
+	}
+	uid := taskgrpc.UserId(ctx, requested)
+	validationReq := requests.GetTaskRequest{ID: id, UID: uid}
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
+		return err
+	}
+	task, err := h.task.GetTask(ctx, id, uid)
+	if err != nil {
+		return err
+	}
+	return h.writeTask(w, http.StatusOK, task)
+}
+
+// updateTask applies a JSON merge patch: the fields present in the body
+// are set exactly as sent, unless an update_mask query parameter names the
+// fields to change instead.
+func (h *handler) updateTask(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	id, err := pathId(r)
+	if err != nil {
+		return err
+	}
+	var req taskv1.UpdateTaskRequest
+	body, err := decodeBody(r, &req)
+	if err != nil {
+		return taskgrpc.InvalidArgument(err)
+	}
+	req.Id = id
+	uid := taskgrpc.UserId(ctx, req.GetUserId())
+	paths := splitList(r.URL.Query()["update_mask"])
+	if len(paths) == 0 {
+		paths = bodyFields(body)
+	}
+	mask, err := taskgrpc.ParseUpdateMask(paths)
+	if err != nil {
+		return taskgrpc.InvalidArgument(err)
+	}
+	update, err := converter.UpdateFromProto(&req, mask)
+	if err != nil {
+		return taskgrpc.InvalidArgument(err)
+	}
+	validationReq := requests.UpdateTaskRequest{
+		ID:          id,
+		UID:         uid,
+		UpdateMask:  update.Mask,
+		Title:       update.Title,
+		Description: update.Description,
+		Priority:    update.Priority.String(),
+		DueDate:     update.DueDate,
+	}
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
+		return err
+	}
+	version, err := taskgrpc.VersionFromIfMatch(r.Header.Get(ifMatchHeader))
+	if err != nil {
+		return taskgrpc.InvalidArgument(err)
+	}
+	task, err := h.task.UpdateTask(ctx, id, uid, version, update)
+	if err != nil {
+		return err
+	}
+	return h.writeTask(w, http.StatusOK, task)
+}
+
+func (h *handler) updateStatus(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	id, err := pathId(r)
+	if err != nil {
+		return err
+	}
+	var req taskv1.UpdateStatusRequest
+	if _, err := decodeBody(r, &req); err != nil {
+		return taskgrpc.InvalidArgument(err)
+	}
+	uid := taskgrpc.UserId(ctx, req.GetUserId())
+	newStatus, err := converter.StatusFromProto(req.GetStatus())
+	if err != nil {
+		return taskgrpc.InvalidArgument(err)
+	}
+	validationReq := requests.UpdateStatusRequest{
+		ID:     id,
+		Status: newStatus.String(),
+		UID:    uid,
+	}
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
+		return err
+	}
+	version, err := taskgrpc.VersionFromIfMatch(r.Header.Get(ifMatchHeader))
+	if err != nil {
+		return taskgrpc.InvalidArgument(err)
+	}
+	task, err := h.task.UpdateStatus(ctx, id, uid, version, newStatus)
+	if err != nil {
+		return err
+	}
+	return h.writeTask(w, http.StatusOK, task)
+}
+
+type statusesResponse struct {
+	Statuses []string `json:"statuses"`
+}
+
+// allowedStatuses lists the statuses a task may move to next, as the
+// X-Next-Statuses header of task responses does.
+func (h *handler) allowedStatuses(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	id, requested, err := pathIds(r)
+	if err != nil {
+		return err
+	}
+	uid := taskgrpc.UserId(ctx, requested)
+	if err := validation.ValidateStruct(ctx, requests.GetTaskRequest{ID: id, UID: uid}); err != nil {
+		return err
+	}
+	statuses, err := h.task.AllowedStatuses(ctx, id, uid)
+	if err != nil {
+		return err
+	}
+	res := statusesResponse{Statuses: make([]string, 0, len(statuses))}
+	for _, status := range statuses {
+		res.Statuses = append(res.Statuses, status.String())
+	}
+	return writeValue(w, http.StatusOK, res)
+}
+
+func (h *handler) deleteTask(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	id, requested, err := pathIds(r)
+	if err != nil {
+		return err
+	}
+	uid := taskgrpc.UserId(ctx, requested)
+	validationReq := requests.DeleteTaskRequest{
+		ID:  id,
+		UID: uid,
+	}
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
+		return err
+	}
+	version, err := taskgrpc.VersionFromIfMatch(r.Header.Get(ifMatchHeader))
+	if err != nil {
+		return taskgrpc.InvalidArgument(err)
+	}
+	if err := h.task.DeleteTask(ctx, id, uid, version); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *handler) listTasks(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	requested, err := queryUint(r, "user_id")
+	if err != nil {
+		return err
+	}
+	uid := taskgrpc.UserId(ctx, requested)
+	query, validationReq, err := listQuery(r)
+	if err != nil {
+		return err
+	}
+	query.UserId = uid
+	validationReq.UID = uid
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
+		return err
+	}
+	page, err := h.task.ListTasks(ctx, query)
+	if err != nil {
+		return err
+	}
+	return h.writePage(w, page)
+}
+
+// pathId returns the task, tag or series id of the URL.
+func pathId(r *http.Request) (uint64, error) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, invalidParam("id", errInvalidId)
+	}
+	return id, nil
+}
+
+// pathIds returns the task id of the URL and the user_id query parameter
+// of requests without a body.
+func pathIds(r *http.Request) (id, uid uint64, err error) {
+	id, err = pathId(r)
+	if err != nil {
+		return 0, 0, err
+	}
+	uid, err = queryUint(r, "user_id")
+	if err != nil {
+		return 0, 0, err
+	}
+	return id, uid, nil
+}
+
+func queryUint(r *http.Request, name string) (uint64, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, invalidParam(name, errInvalidId)
+	}
+	return v, nil
+}
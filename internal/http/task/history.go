@@ -0,0 +1,65 @@
+package taskhttp
+
+import (
+	"github.com/Citadelas/task/internal/domain/models"
+	taskgrpc "github.com/Citadelas/task/internal/grpc/task"
+	"github.com/Citadelas/task/internal/grpc/validation"
+	"github.com/Citadelas/task/internal/grpc/validation/requests"
+	"net/http"
+	"time"
+)
+
+type historyEntryResponse struct {
+	Id        uint64              `json:"id,string"`
+	TaskId    uint64              `json:"task_id,string"`
+	ActorId   uint64              `json:"actor_id,string"`
+	Action    string              `json:"action"`
+	Changes   models.FieldChanges `json:"changes"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+type historyResponse struct {
+	Entries       []historyEntryResponse `json:"entries"`
+	NextPageToken string                 `json:"next_page_token"`
+}
+
+// taskHistory pages through the changes made to a task, oldest first.
+func (h *handler) taskHistory(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	id, requested, err := pathIds(r)
+	if err != nil {
+		return err
+	}
+	uid := taskgrpc.UserId(ctx, requested)
+	pageSize, err := queryPageSize(r)
+	if err != nil {
+		return err
+	}
+	validationReq := requests.TaskHistoryRequest{ID: id, UID: uid, PageSize: pageSize}
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
+		return err
+	}
+	page, err := h.task.GetTaskHistory(ctx, id, uid, pageSize, r.URL.Query().Get("page_token"))
+	if err != nil {
+		return err
+	}
+	res := historyResponse{
+		Entries:       make([]historyEntryResponse, 0, len(page.Entries)),
+		NextPageToken: page.NextPageToken,
+	}
+	for _, entry := range page.Entries {
+		changes := entry.Changes
+		if changes == nil {
+			changes = models.FieldChanges{}
+		}
+		res.Entries = append(res.Entries, historyEntryResponse{
+			Id:        entry.Id,
+			TaskId:    entry.TaskId,
+			ActorId:   entry.ActorId,
+			Action:    entry.Action,
+			Changes:   changes,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+	return writeValue(w, http.StatusOK, res)
+}
@@ -0,0 +1,190 @@
+package taskhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	taskv1 "github.com/Citadelas/protos/golang/task"
+	"github.com/Citadelas/task/internal/domain/models"
+	taskgrpc "github.com/Citadelas/task/internal/grpc/task"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxBodySize bounds request bodies; the largest valid request is a few
+// kilobytes.
+const maxBodySize = 1 << 20
+
+var (
+	unmarshalOptions = protojson.UnmarshalOptions{}
+	marshalOptions   = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+	// Error details leave out empty fields, as gRPC clients see them.
+	statusMarshalOptions = protojson.MarshalOptions{UseProtoNames: true}
+)
+
+var errEmptyBody = errors.New("request body must be a JSON object")
+
+// decodeBody reads a JSON request body into msg and returns the raw body.
+func decodeBody(r *http.Request, msg proto.Message) ([]byte, error) {
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := unmarshalOptions.Unmarshal(body, msg); err != nil {
+		return nil, fmt.Errorf("decode body: %w", err)
+	}
+	return body, nil
+}
+
+// decodeJSON reads the JSON body of an endpoint without a gRPC message
+// into v. Unknown fields are rejected, as decodeBody does.
+func decodeJSON(r *http.Request, v any) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("decode body: %w", err)
+	}
+	return nil
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return nil, errEmptyBody
+	}
+	return body, nil
+}
+
+// idValue is an id in a decodeJSON body. Like the protobuf JSON mapping of
+// uint64 it accepts both a number and a decimal string.
+type idValue uint64
+
+func (v *idValue) UnmarshalJSON(data []byte) error {
+	raw := string(data)
+	if unquoted, err := strconv.Unquote(raw); err == nil {
+		raw = unquoted
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return fmt.Errorf("id %s %w", data, errInvalidId)
+	}
+	*v = idValue(id)
+	return nil
+}
+
+// bodyFields lists the UpdateTaskRequest fields present in a JSON body by
+// their proto name, leaving out the identifying ones. decodeBody has
+// already rejected unknown fields.
+func bodyFields(body []byte) []string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil
+	}
+	descriptor := (&taskv1.UpdateTaskRequest{}).ProtoReflect().Descriptor().Fields()
+	var paths []string
+	for key := range fields {
+		field := descriptor.ByJSONName(key)
+		if field == nil {
+			field = descriptor.ByName(protoreflect.Name(key))
+		}
+		if field == nil || field.Name() == "id" || field.Name() == "user_id" {
+			continue
+		}
+		paths = append(paths, string(field.Name()))
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// splitList flattens repeated, comma-separated query values.
+func splitList(values []string) []string {
+	var res []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				res = append(res, item)
+			}
+		}
+	}
+	return res
+}
+
+func (h *handler) writeTask(w http.ResponseWriter, code int, task *models.Task) error {
+	res, err := h.adapter.ToProto(task)
+	if err != nil {
+		return err
+	}
+	body, err := marshalOptions.Marshal(res)
+	if err != nil {
+		return err
+	}
+	w.Header().Set(etagHeader, taskgrpc.ETag(task))
+	w.Header().Set(nextStatusesHeader, taskgrpc.JoinStatuses(h.task.NextStatuses(task.Status)))
+	writeJSON(w, code, body)
+	return nil
+}
+
+type pageResponse struct {
+	Tasks         []json.RawMessage `json:"tasks"`
+	NextPageToken string            `json:"next_page_token"`
+}
+
+func (h *handler) writePage(w http.ResponseWriter, page *models.TaskPage) error {
+	tasks, err := h.tasksJSON(page.Tasks)
+	if err != nil {
+		return err
+	}
+	return writeValue(w, http.StatusOK, pageResponse{Tasks: tasks, NextPageToken: page.NextPageToken})
+}
+
+// taskJSON is the protobuf JSON form of a task, for embedding in the
+// responses of endpoints without a gRPC message.
+func (h *handler) taskJSON(task *models.Task) (json.RawMessage, error) {
+	protoTask, err := h.adapter.ToProto(task)
+	if err != nil {
+		return nil, err
+	}
+	return marshalOptions.Marshal(protoTask)
+}
+
+func (h *handler) tasksJSON(tasks []*models.Task) ([]json.RawMessage, error) {
+	res := make([]json.RawMessage, 0, len(tasks))
+	for _, task := range tasks {
+		body, err := h.taskJSON(task)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, body)
+	}
+	return res, nil
+}
+
+// writeValue writes v with encoding/json.
+func writeValue(w http.ResponseWriter, code int, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, code, body)
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,96 @@
+package taskhttp
+
+import (
+	"errors"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/grpc/validation/requests"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	errInvalidPageSize = errors.New("must be an integer")
+	errInvalidBool     = errors.New("must be true or false")
+	errInvalidTime     = errors.New("must be an RFC 3339 timestamp")
+	errInvalidTagMatch = errors.New("must be one of: any, all")
+)
+
+// listQuery reads the listing parameters of a GET /v1/tasks request:
+// status, priority and tag (repeated or comma separated), tag_match,
+// due_from, due_to, sort_by, desc, page_size and page_token.
+func listQuery(r *http.Request) (models.ListTasksQuery, requests.ListTasksRequest, error) {
+	params := r.URL.Query()
+	query := models.ListTasksQuery{
+		SortBy:    models.TaskSortField(params.Get("sort_by")),
+		PageToken: params.Get("page_token"),
+		Filter: models.TaskFilter{
+			Tags:     splitList(params["tag"]),
+			TagMatch: models.TagMatch(params.Get("tag_match")),
+		},
+	}
+	validationReq := requests.ListTasksRequest{
+		SortBy:     params.Get("sort_by"),
+		Statuses:   splitList(params["status"]),
+		Priorities: splitList(params["priority"]),
+	}
+	var err error
+	if query.PageSize, err = queryPageSize(r); err != nil {
+		return query, validationReq, err
+	}
+	validationReq.PageSize = query.PageSize
+	if raw := params.Get("desc"); raw != "" {
+		if query.Desc, err = strconv.ParseBool(raw); err != nil {
+			return query, validationReq, invalidParam("desc", errInvalidBool)
+		}
+	}
+	switch query.Filter.TagMatch {
+	case "":
+		query.Filter.TagMatch = models.TagMatchAny
+	case models.TagMatchAny, models.TagMatchAll:
+	default:
+		return query, validationReq, invalidParam("tag_match", errInvalidTagMatch)
+	}
+	if query.Filter.DueFrom, err = queryTime(r, "due_from"); err != nil {
+		return query, validationReq, err
+	}
+	if query.Filter.DueTo, err = queryTime(r, "due_to"); err != nil {
+		return query, validationReq, err
+	}
+	// Unknown names are left for ValidateStruct to report.
+	for _, name := range validationReq.Statuses {
+		if status, err := models.ParseStatus(name); err == nil {
+			query.Filter.Statuses = append(query.Filter.Statuses, status)
+		}
+	}
+	for _, name := range validationReq.Priorities {
+		if priority, err := models.ParsePriority(name); err == nil {
+			query.Filter.Priorities = append(query.Filter.Priorities, priority)
+		}
+	}
+	return query, validationReq, nil
+}
+
+func queryTime(r *http.Request, name string) (*time.Time, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, invalidParam(name, errInvalidTime)
+	}
+	return &t, nil
+}
+
+func queryPageSize(r *http.Request) (int, error) {
+	raw := r.URL.Query().Get("page_size")
+	if raw == "" {
+		return 0, nil
+	}
+	size, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, invalidParam("page_size", errInvalidPageSize)
+	}
+	return size, nil
+}
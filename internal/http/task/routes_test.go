@@ -0,0 +1,290 @@
+package taskhttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	taskservice "github.com/Citadelas/task/internal/services/task"
+	"github.com/Citadelas/task/internal/storage/memory"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// routeClient calls the gateway over a memory-backed service as one user.
+type routeClient struct {
+	t       *testing.T
+	srv     *httptest.Server
+	service *taskservice.Task
+	uid     uint64
+}
+
+func newRouteClient(t *testing.T) *routeClient {
+	t.Helper()
+	workflow, err := models.NewWorkflow(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := memory.New()
+	log := slog.New(slog.DiscardHandler)
+	service := taskservice.New(log, s, s, s, s, s, s, s, s, s, s, s, s, workflow)
+	mux := http.NewServeMux()
+	Register(mux, log, service, make(chan struct{}))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return &routeClient{t: t, srv: srv, service: service, uid: 7}
+}
+
+// do sends body, if any, and decodes the response into res unless it is
+// nil. It fails the test when the response status is not want.
+func (c *routeClient) do(method, path, body string, want int, res any) {
+	c.t.Helper()
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	url := fmt.Sprintf("%s%s%suser_id=%d", c.srv.URL, path, sep, c.uid)
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	resp, err := c.srv.Client().Do(req)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if resp.StatusCode != want {
+		c.t.Fatalf("%s %s = %d %s, want %d", method, path, resp.StatusCode, raw, want)
+	}
+	if res != nil {
+		if err := json.Unmarshal(raw, res); err != nil {
+			c.t.Fatalf("%s %s: decode %s: %v", method, path, raw, err)
+		}
+	}
+}
+
+// body is a JSON object with the client's user_id and fields.
+func (c *routeClient) body(fields string) string {
+	if fields == "" {
+		return fmt.Sprintf(`{"user_id":"%d"}`, c.uid)
+	}
+	return fmt.Sprintf(`{"user_id":"%d",%s}`, c.uid, fields)
+}
+
+type taskBody struct {
+	Id    uint64 `json:"id,string"`
+	Title string `json:"title"`
+}
+
+func (c *routeClient) createTask(title string) uint64 {
+	c.t.Helper()
+	var res taskBody
+	body := c.body(fmt.Sprintf(`"title":%q,"description":"d","due_date":"2030-01-01T09:00:00Z"`, title))
+	c.do(http.MethodPost, "/v1/tasks", body, http.StatusCreated, &res)
+	return res.Id
+}
+
+type errorBody struct {
+	Details []struct {
+		Reason          string `json:"reason"`
+		FieldViolations []struct {
+			Field string `json:"field"`
+		} `json:"field_violations"`
+	} `json:"details"`
+}
+
+func TestStatusesAndHistoryRoutes(t *testing.T) {
+	c := newRouteClient(t)
+	id := c.createTask("a")
+
+	var statuses statusesResponse
+	c.do(http.MethodGet, fmt.Sprintf("/v1/tasks/%d/statuses", id), "", http.StatusOK, &statuses)
+	if !slices.Contains(statuses.Statuses, models.StatusInProgress.String()) {
+		t.Fatalf("statuses = %v", statuses.Statuses)
+	}
+
+	c.do(http.MethodPatch, fmt.Sprintf("/v1/tasks/%d", id), c.body(`"title":"b"`), http.StatusOK, nil)
+	var history historyResponse
+	c.do(http.MethodGet, fmt.Sprintf("/v1/tasks/%d/history?page_size=1", id), "", http.StatusOK, &history)
+	if len(history.Entries) != 1 || history.Entries[0].Action != models.ActionCreated || history.NextPageToken == "" {
+		t.Fatalf("first page = %+v", history)
+	}
+	c.do(http.MethodGet, fmt.Sprintf("/v1/tasks/%d/history?page_token=%s", id, history.NextPageToken), "",
+		http.StatusOK, &history)
+	if len(history.Entries) != 1 || history.Entries[0].Action != models.ActionUpdated ||
+		!slices.ContainsFunc(history.Entries[0].Changes, func(c models.FieldChange) bool { return c.Field == models.FieldTitle }) {
+		t.Fatalf("second page = %+v", history)
+	}
+	c.do(http.MethodGet, fmt.Sprintf("/v1/tasks/%d/history?page_size=x", id), "", http.StatusBadRequest, nil)
+}
+
+func TestTagRoutes(t *testing.T) {
+	c := newRouteClient(t)
+	id := c.createTask("a")
+
+	c.do(http.MethodPatch, fmt.Sprintf("/v1/tasks/%d/tags", id), c.body(`"add":["Work"]`), http.StatusOK, nil)
+	var tags tagsResponse
+	c.do(http.MethodGet, "/v1/tags", "", http.StatusOK, &tags)
+	if len(tags.Tags) != 1 || tags.Tags[0].Name != "work" {
+		t.Fatalf("tags = %+v", tags)
+	}
+	tagId := tags.Tags[0].Id
+
+	var created tagResponse
+	c.do(http.MethodPost, "/v1/tags", c.body(`"name":"home"`), http.StatusCreated, &created)
+	var failure errorBody
+	c.do(http.MethodPost, "/v1/tags", c.body(`"name":"home"`), http.StatusConflict, &failure)
+	if failure.Details[0].Reason != "TAG_EXISTS" {
+		t.Fatalf("duplicate tag = %+v", failure)
+	}
+	c.do(http.MethodPatch, fmt.Sprintf("/v1/tags/%d", tagId), c.body(`"name":"job"`), http.StatusOK, &created)
+	if created.Name != "job" {
+		t.Fatalf("renamed tag = %+v", created)
+	}
+	task, err := c.service.GetTask(context.Background(), id, c.uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(task.Tags, []string{"job"}) {
+		t.Fatalf("task tags = %v", task.Tags)
+	}
+	c.do(http.MethodDelete, fmt.Sprintf("/v1/tags/%d", tagId), "", http.StatusNoContent, nil)
+	c.do(http.MethodDelete, fmt.Sprintf("/v1/tags/%d", tagId), "", http.StatusNotFound, &failure)
+	if failure.Details[0].Reason != "TAG_NOT_FOUND" {
+		t.Fatalf("missing tag = %+v", failure)
+	}
+	c.do(http.MethodPatch, fmt.Sprintf("/v1/tasks/%d/tags", id), c.body(""), http.StatusBadRequest, nil)
+	c.do(http.MethodPatch, fmt.Sprintf("/v1/tasks/%d/tags", id), c.body(`"add":["x"],"other":1`),
+		http.StatusBadRequest, nil)
+}
+
+func TestHierarchyRoutes(t *testing.T) {
+	c := newRouteClient(t)
+	parent := c.createTask("parent")
+
+	var child taskBody
+	c.do(http.MethodPost, fmt.Sprintf("/v1/tasks/%d/subtasks", parent),
+		c.body(`"title":"child","description":"d"`), http.StatusCreated, &child)
+	var tree treeResponse
+	c.do(http.MethodGet, fmt.Sprintf("/v1/tasks/%d/subtree", parent), "", http.StatusOK, &tree)
+	if len(tree.Children) != 1 || tree.Progress != 0 {
+		t.Fatalf("tree = %+v", tree)
+	}
+	var node taskBody
+	if err := json.Unmarshal(tree.Children[0].Task, &node); err != nil || node.Id != child.Id {
+		t.Fatalf("child = %s, %v", tree.Children[0].Task, err)
+	}
+
+	var failure errorBody
+	c.do(http.MethodPut, fmt.Sprintf("/v1/tasks/%d/parent", parent),
+		c.body(fmt.Sprintf(`"parent_id":%d`, child.Id)), http.StatusBadRequest, &failure)
+	if failure.Details[0].Reason != "HIERARCHY_CYCLE" {
+		t.Fatalf("cycle = %+v", failure)
+	}
+	c.do(http.MethodPut, fmt.Sprintf("/v1/tasks/%d/parent", child.Id), c.body(`"parent_id":null`), http.StatusOK, nil)
+	c.do(http.MethodGet, fmt.Sprintf("/v1/tasks/%d/subtree", parent), "", http.StatusOK, &tree)
+	if len(tree.Children) != 0 {
+		t.Fatalf("tree after move = %+v", tree)
+	}
+	c.do(http.MethodPut, fmt.Sprintf("/v1/tasks/%d/parent", child.Id), c.body(`"parent_id":"x"`),
+		http.StatusBadRequest, nil)
+}
+
+func TestBlockerRoutes(t *testing.T) {
+	c := newRouteClient(t)
+	a, b := c.createTask("a"), c.createTask("b")
+
+	c.do(http.MethodPut, fmt.Sprintf("/v1/tasks/%d/blockers/%d", a, b), "", http.StatusNoContent, nil)
+	var blockers tasksResponse
+	c.do(http.MethodGet, fmt.Sprintf("/v1/tasks/%d/blockers", a), "", http.StatusOK, &blockers)
+	if len(blockers.Tasks) != 1 {
+		t.Fatalf("blockers = %s", blockers.Tasks)
+	}
+	var failure errorBody
+	c.do(http.MethodPut, fmt.Sprintf("/v1/tasks/%d/blockers/%d", b, a), "", http.StatusBadRequest, &failure)
+	if failure.Details[0].Reason != "DEPENDENCY_CYCLE" {
+		t.Fatalf("cycle = %+v", failure)
+	}
+	c.do(http.MethodDelete, fmt.Sprintf("/v1/tasks/%d/blockers/%d", a, b), "", http.StatusNoContent, nil)
+	c.do(http.MethodDelete, fmt.Sprintf("/v1/tasks/%d/blockers/%d", a, b), "", http.StatusNotFound, &failure)
+	if failure.Details[0].Reason != "BLOCKER_NOT_FOUND" {
+		t.Fatalf("missing blocker = %+v", failure)
+	}
+	c.do(http.MethodPut, fmt.Sprintf("/v1/tasks/%d/blockers/0", a), "", http.StatusBadRequest, nil)
+}
+
+func TestSeriesRoutes(t *testing.T) {
+	c := newRouteClient(t)
+	id := c.createTask("a")
+
+	var failure errorBody
+	c.do(http.MethodPut, fmt.Sprintf("/v1/tasks/%d/recurrence", id), c.body(`"rule":"FREQ=SOMETIMES"`),
+		http.StatusBadRequest, &failure)
+	if violations := failure.Details[0].FieldViolations; len(violations) != 1 || violations[0].Field != "rule" {
+		t.Fatalf("invalid rule = %+v", failure)
+	}
+	c.do(http.MethodPut, fmt.Sprintf("/v1/tasks/%d/recurrence", id), c.body(`"rule":"FREQ=DAILY"`), http.StatusOK, nil)
+	task, err := c.service.GetTask(context.Background(), id, c.uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.SeriesId == nil {
+		t.Fatal("task is not part of a series")
+	}
+	path := fmt.Sprintf("/v1/series/%d", *task.SeriesId)
+
+	var series seriesResponse
+	c.do(http.MethodGet, path, "", http.StatusOK, &series)
+	if series.Rule != "FREQ=DAILY" || series.Stopped {
+		t.Fatalf("series = %+v", series)
+	}
+	c.do(http.MethodPatch, path, c.body(`"rule":"FREQ=WEEKLY"`), http.StatusOK, &series)
+	if series.Rule != "FREQ=WEEKLY" {
+		t.Fatalf("updated series = %+v", series)
+	}
+	c.do(http.MethodPost, path+"/stop", "", http.StatusOK, &series)
+	if !series.Stopped {
+		t.Fatalf("stopped series = %+v", series)
+	}
+	c.do(http.MethodGet, fmt.Sprintf("/v1/series/%d", *task.SeriesId+1), "", http.StatusNotFound, &failure)
+	if failure.Details[0].Reason != "SERIES_NOT_FOUND" {
+		t.Fatalf("missing series = %+v", failure)
+	}
+}
+
+func TestTrashRoutes(t *testing.T) {
+	c := newRouteClient(t)
+	id := c.createTask("a")
+	path := fmt.Sprintf("/v1/tasks/%d", id)
+
+	c.do(http.MethodDelete, path, "", http.StatusNoContent, nil)
+	var page pageResponse
+	c.do(http.MethodGet, "/v1/trash", "", http.StatusOK, &page)
+	if len(page.Tasks) != 1 {
+		t.Fatalf("trash = %s", page.Tasks)
+	}
+	c.do(http.MethodPost, fmt.Sprintf("/v1/trash/%d/restore", id), "", http.StatusOK, nil)
+	c.do(http.MethodGet, path, "", http.StatusOK, nil)
+
+	c.do(http.MethodDelete, path, "", http.StatusNoContent, nil)
+	c.do(http.MethodDelete, fmt.Sprintf("/v1/trash/%d", id), "", http.StatusNoContent, nil)
+	c.do(http.MethodGet, "/v1/trash", "", http.StatusOK, &page)
+	if len(page.Tasks) != 0 {
+		t.Fatalf("trash after purge = %s", page.Tasks)
+	}
+	c.do(http.MethodPost, fmt.Sprintf("/v1/trash/%d/restore", id), "", http.StatusNotFound, nil)
+	c.do(http.MethodGet, "/v1/trash?page_size=501", "", http.StatusBadRequest, nil)
+}
@@ -0,0 +1,117 @@
+package taskhttp
+
+import (
+	"github.com/Citadelas/task/internal/domain/models"
+	taskgrpc "github.com/Citadelas/task/internal/grpc/task"
+	"github.com/Citadelas/task/internal/grpc/validation"
+	"github.com/Citadelas/task/internal/grpc/validation/requests"
+	"net/http"
+	"time"
+)
+
+type ruleRequest struct {
+	UserId idValue `json:"user_id"`
+	Rule   string  `json:"rule"`
+}
+
+type seriesResponse struct {
+	Id        uint64    `json:"id,string"`
+	Rule      string    `json:"rule"`
+	StartsAt  time.Time `json:"starts_at"`
+	Stopped   bool      `json:"stopped"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newSeriesResponse(series *models.Series) seriesResponse {
+	return seriesResponse{
+		Id:        series.Id,
+		Rule:      series.Rule,
+		StartsAt:  series.StartsAt,
+		Stopped:   series.Stopped,
+		CreatedAt: series.CreatedAt,
+	}
+}
+
+// makeRecurring turns a task with a due date into the first occurrence of
+// a series following the RRULE of the body.
+func (h *handler) makeRecurring(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	id, err := pathId(r)
+	if err != nil {
+		return err
+	}
+	var req ruleRequest
+	if err := decodeJSON(r, &req); err != nil {
+		return taskgrpc.InvalidArgument(err)
+	}
+	uid := taskgrpc.UserId(ctx, uint64(req.UserId))
+	validationReq := requests.RecurrenceRequest{ID: id, UID: uid, Rule: req.Rule}
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
+		return err
+	}
+	task, err := h.task.MakeRecurring(ctx, id, uid, req.Rule)
+	if err != nil {
+		return err
+	}
+	return h.writeTask(w, http.StatusOK, task)
+}
+
+func (h *handler) getSeries(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	id, requested, err := pathIds(r)
+	if err != nil {
+		return err
+	}
+	uid := taskgrpc.UserId(ctx, requested)
+	if err := validation.ValidateStruct(ctx, requests.SeriesRequest{ID: id, UID: uid}); err != nil {
+		return err
+	}
+	series, err := h.task.GetSeries(ctx, id, uid)
+	if err != nil {
+		return err
+	}
+	return writeValue(w, http.StatusOK, newSeriesResponse(series))
+}
+
+// updateSeries replaces the rule of a series; occurrences created from then
+// on follow the new rule.
+func (h *handler) updateSeries(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	id, err := pathId(r)
+	if err != nil {
+		return err
+	}
+	var req ruleRequest
+	if err := decodeJSON(r, &req); err != nil {
+		return taskgrpc.InvalidArgument(err)
+	}
+	uid := taskgrpc.UserId(ctx, uint64(req.UserId))
+	validationReq := requests.RecurrenceRequest{ID: id, UID: uid, Rule: req.Rule}
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
+		return err
+	}
+	series, err := h.task.UpdateSeries(ctx, id, uid, req.Rule)
+	if err != nil {
+		return err
+	}
+	return writeValue(w, http.StatusOK, newSeriesResponse(series))
+}
+
+// stopSeries ends a series: completing its open occurrence no longer
+// creates the next one.
+func (h *handler) stopSeries(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	id, requested, err := pathIds(r)
+	if err != nil {
+		return err
+	}
+	uid := taskgrpc.UserId(ctx, requested)
+	if err := validation.ValidateStruct(ctx, requests.SeriesRequest{ID: id, UID: uid}); err != nil {
+		return err
+	}
+	series, err := h.task.StopSeries(ctx, id, uid)
+	if err != nil {
+		return err
+	}
+	return writeValue(w, http.StatusOK, newSeriesResponse(series))
+}
@@ -0,0 +1,144 @@
+package taskhttp
+
+import (
+	"github.com/Citadelas/task/internal/domain/models"
+	taskgrpc "github.com/Citadelas/task/internal/grpc/task"
+	"github.com/Citadelas/task/internal/grpc/validation"
+	"github.com/Citadelas/task/internal/grpc/validation/requests"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type tagRequest struct {
+	UserId idValue `json:"user_id"`
+	Name   string  `json:"name"`
+}
+
+type taskTagsRequest struct {
+	UserId idValue  `json:"user_id"`
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+type tagResponse struct {
+	Id        uint64    `json:"id,string"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type tagsResponse struct {
+	Tags []tagResponse `json:"tags"`
+}
+
+func newTagResponse(tag *models.Tag) tagResponse {
+	return tagResponse{Id: tag.Id, Name: tag.Name, CreatedAt: tag.CreatedAt}
+}
+
+func (h *handler) listTags(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	requested, err := queryUint(r, "user_id")
+	if err != nil {
+		return err
+	}
+	uid := taskgrpc.UserId(ctx, requested)
+	if err := validation.ValidateStruct(ctx, requests.ListTagsRequest{UID: uid}); err != nil {
+		return err
+	}
+	tags, err := h.task.ListTags(ctx, uid)
+	if err != nil {
+		return err
+	}
+	res := tagsResponse{Tags: make([]tagResponse, 0, len(tags))}
+	for _, tag := range tags {
+		res.Tags = append(res.Tags, newTagResponse(tag))
+	}
+	return writeValue(w, http.StatusOK, res)
+}
+
+func (h *handler) createTag(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	var req tagRequest
+	if err := decodeJSON(r, &req); err != nil {
+		return taskgrpc.InvalidArgument(err)
+	}
+	uid := taskgrpc.UserId(ctx, uint64(req.UserId))
+	validationReq := requests.CreateTagRequest{UID: uid, Name: req.Name}
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
+		return err
+	}
+	tag, err := h.task.CreateTag(ctx, uid, req.Name)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Location", "/v1/tags/"+strconv.FormatUint(tag.Id, 10))
+	return writeValue(w, http.StatusCreated, newTagResponse(tag))
+}
+
+// renameTag renames a tag on every task that carries it.
+func (h *handler) renameTag(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	id, err := pathId(r)
+	if err != nil {
+		return err
+	}
+	var req tagRequest
+	if err := decodeJSON(r, &req); err != nil {
+		return taskgrpc.InvalidArgument(err)
+	}
+	uid := taskgrpc.UserId(ctx, uint64(req.UserId))
+	validationReq := requests.RenameTagRequest{ID: id, UID: uid, Name: req.Name}
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
+		return err
+	}
+	tag, err := h.task.RenameTag(ctx, id, uid, req.Name)
+	if err != nil {
+		return err
+	}
+	return writeValue(w, http.StatusOK, newTagResponse(tag))
+}
+
+func (h *handler) deleteTag(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	id, requested, err := pathIds(r)
+	if err != nil {
+		return err
+	}
+	uid := taskgrpc.UserId(ctx, requested)
+	if err := validation.ValidateStruct(ctx, requests.DeleteTagRequest{ID: id, UID: uid}); err != nil {
+		return err
+	}
+	if err := h.task.DeleteTag(ctx, id, uid); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// updateTaskTags attaches and detaches tags by name, creating the tags
+// that do not exist yet. It honours If-Match like PATCH /v1/tasks/{id}.
+func (h *handler) updateTaskTags(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	id, err := pathId(r)
+	if err != nil {
+		return err
+	}
+	var req taskTagsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		return taskgrpc.InvalidArgument(err)
+	}
+	uid := taskgrpc.UserId(ctx, uint64(req.UserId))
+	validationReq := requests.TaskTagsRequest{ID: id, UID: uid, Add: req.Add, Remove: req.Remove}
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
+		return err
+	}
+	version, err := taskgrpc.VersionFromIfMatch(r.Header.Get(ifMatchHeader))
+	if err != nil {
+		return taskgrpc.InvalidArgument(err)
+	}
+	task, err := h.task.UpdateTask(ctx, id, uid, version, models.TaskUpdate{AddTags: req.Add, RemoveTags: req.Remove})
+	if err != nil {
+		return err
+	}
+	return h.writeTask(w, http.StatusOK, task)
+}
@@ -0,0 +1,69 @@
+package taskhttp
+
+import (
+	taskgrpc "github.com/Citadelas/task/internal/grpc/task"
+	"github.com/Citadelas/task/internal/grpc/validation"
+	"github.com/Citadelas/task/internal/grpc/validation/requests"
+	"net/http"
+)
+
+// listTrash pages through the deleted tasks of a user, most recently
+// deleted first, taking page_size and page_token as GET /v1/tasks does.
+func (h *handler) listTrash(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	requested, err := queryUint(r, "user_id")
+	if err != nil {
+		return err
+	}
+	uid := taskgrpc.UserId(ctx, requested)
+	pageSize, err := queryPageSize(r)
+	if err != nil {
+		return err
+	}
+	validationReq := requests.ListTrashRequest{UID: uid, PageSize: pageSize}
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
+		return err
+	}
+	page, err := h.task.ListTrash(ctx, uid, pageSize, r.URL.Query().Get("page_token"))
+	if err != nil {
+		return err
+	}
+	return h.writePage(w, page)
+}
+
+func (h *handler) restoreTask(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	id, requested, err := pathIds(r)
+	if err != nil {
+		return err
+	}
+	uid := taskgrpc.UserId(ctx, requested)
+	validationReq := requests.GetTaskRequest{ID: id, UID: uid}
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
+		return err
+	}
+	task, err := h.task.RestoreTask(ctx, id, uid)
+	if err != nil {
+		return err
+	}
+	return h.writeTask(w, http.StatusOK, task)
+}
+
+// purgeTask permanently removes a task that is already in the trash.
+func (h *handler) purgeTask(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	id, requested, err := pathIds(r)
+	if err != nil {
+		return err
+	}
+	uid := taskgrpc.UserId(ctx, requested)
+	validationReq := requests.DeleteTaskRequest{ID: id, UID: uid}
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
+		return err
+	}
+	if err := h.task.PurgeTask(ctx, id, uid); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
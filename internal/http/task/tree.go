@@ -0,0 +1,197 @@
+package taskhttp
+
+import (
+	"context"
+	"encoding/json"
+	taskv1 "github.com/Citadelas/protos/golang/task"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/grpc/converter"
+	taskgrpc "github.com/Citadelas/task/internal/grpc/task"
+	"github.com/Citadelas/task/internal/grpc/validation"
+	"github.com/Citadelas/task/internal/grpc/validation/requests"
+	"net/http"
+	"strconv"
+)
+
+type moveRequest struct {
+	UserId idValue `json:"user_id"`
+	// ParentId is the new parent; null or absent moves the task to the
+	// top level.
+	ParentId *idValue `json:"parent_id"`
+}
+
+type treeResponse struct {
+	Task     json.RawMessage `json:"task"`
+	Progress int             `json:"progress"`
+	Children []treeResponse  `json:"children"`
+}
+
+type tasksResponse struct {
+	Tasks []json.RawMessage `json:"tasks"`
+}
+
+// createSubtask creates a task under the task of the URL. The body is the
+// one of POST /v1/tasks.
+func (h *handler) createSubtask(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	parentId, err := pathId(r)
+	if err != nil {
+		return err
+	}
+	var req taskv1.CreateTaskRequest
+	if _, err := decodeBody(r, &req); err != nil {
+		return taskgrpc.InvalidArgument(err)
+	}
+	uid := taskgrpc.UserId(ctx, req.GetUserId())
+	newTask, err := converter.CreateFromProto(&req)
+	if err != nil {
+		return taskgrpc.InvalidArgument(err)
+	}
+	validationReq := requests.CreateTaskRequest{
+		UID:         uid,
+		Title:       newTask.Title,
+		Description: newTask.Description,
+		Priority:    newTask.Priority.String(),
+		DueDate:     newTask.DueDate,
+	}
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
+		return err
+	}
+	task, err := h.task.CreateSubtask(ctx, parentId, uid, newTask.Title, newTask.Description, newTask.Priority,
+		newTask.DueDate, nil)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Location", "/v1/tasks/"+strconv.FormatUint(task.Id, 10))
+	return h.writeTask(w, http.StatusCreated, task)
+}
+
+// moveTask places a task under another one or at the top level. It
+// honours If-Match like PATCH /v1/tasks/{id}.
+func (h *handler) moveTask(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	id, err := pathId(r)
+	if err != nil {
+		return err
+	}
+	var req moveRequest
+	if err := decodeJSON(r, &req); err != nil {
+		return taskgrpc.InvalidArgument(err)
+	}
+	uid := taskgrpc.UserId(ctx, uint64(req.UserId))
+	var parentId *uint64
+	if req.ParentId != nil {
+		parent := uint64(*req.ParentId)
+		parentId = &parent
+	}
+	validationReq := requests.MoveTaskRequest{ID: id, UID: uid, ParentID: parentId}
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
+		return err
+	}
+	version, err := taskgrpc.VersionFromIfMatch(r.Header.Get(ifMatchHeader))
+	if err != nil {
+		return taskgrpc.InvalidArgument(err)
+	}
+	task, err := h.task.MoveTask(ctx, id, uid, version, parentId)
+	if err != nil {
+		return err
+	}
+	return h.writeTask(w, http.StatusOK, task)
+}
+
+// getSubtree returns a task with all of its subtasks and their completion
+// progress.
+func (h *handler) getSubtree(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	id, requested, err := pathIds(r)
+	if err != nil {
+		return err
+	}
+	uid := taskgrpc.UserId(ctx, requested)
+	if err := validation.ValidateStruct(ctx, requests.GetTaskRequest{ID: id, UID: uid}); err != nil {
+		return err
+	}
+	tree, err := h.task.GetSubtree(ctx, id, uid)
+	if err != nil {
+		return err
+	}
+	res, err := h.treeJSON(tree)
+	if err != nil {
+		return err
+	}
+	return writeValue(w, http.StatusOK, res)
+}
+
+func (h *handler) treeJSON(tree *models.TaskTree) (treeResponse, error) {
+	task, err := h.taskJSON(tree.Task)
+	if err != nil {
+		return treeResponse{}, err
+	}
+	res := treeResponse{
+		Task:     task,
+		Progress: tree.Progress,
+		Children: make([]treeResponse, 0, len(tree.Children)),
+	}
+	for _, child := range tree.Children {
+		node, err := h.treeJSON(child)
+		if err != nil {
+			return treeResponse{}, err
+		}
+		res.Children = append(res.Children, node)
+	}
+	return res, nil
+}
+
+func (h *handler) listBlockers(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	id, requested, err := pathIds(r)
+	if err != nil {
+		return err
+	}
+	uid := taskgrpc.UserId(ctx, requested)
+	if err := validation.ValidateStruct(ctx, requests.GetTaskRequest{ID: id, UID: uid}); err != nil {
+		return err
+	}
+	blockers, err := h.task.ListBlockers(ctx, id, uid)
+	if err != nil {
+		return err
+	}
+	tasks, err := h.tasksJSON(blockers)
+	if err != nil {
+		return err
+	}
+	return writeValue(w, http.StatusOK, tasksResponse{Tasks: tasks})
+}
+
+// addBlocker records that the task {blocker_id} must be done before the
+// task {id} can start.
+func (h *handler) addBlocker(w http.ResponseWriter, r *http.Request) error {
+	return h.changeBlocker(w, r, h.task.AddBlocker)
+}
+
+func (h *handler) removeBlocker(w http.ResponseWriter, r *http.Request) error {
+	return h.changeBlocker(w, r, h.task.RemoveBlocker)
+}
+
+func (h *handler) changeBlocker(w http.ResponseWriter, r *http.Request,
+	change func(ctx context.Context, id, blockerId, uid uint64) error) error {
+	ctx := r.Context()
+	id, requested, err := pathIds(r)
+	if err != nil {
+		return err
+	}
+	blockerId, err := strconv.ParseUint(r.PathValue("blocker_id"), 10, 64)
+	if err != nil || blockerId == 0 {
+		return invalidParam("blocker_id", errInvalidId)
+	}
+	uid := taskgrpc.UserId(ctx, requested)
+	validationReq := requests.BlockerRequest{ID: id, UID: uid, BlockerID: blockerId}
+	if err := validation.ValidateStruct(ctx, validationReq); err != nil {
+		return err
+	}
+	if err := change(ctx, id, blockerId, uid); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,195 @@
+package taskhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	taskgrpc "github.com/Citadelas/task/internal/grpc/task"
+	"github.com/Citadelas/task/internal/grpc/validation"
+	"github.com/Citadelas/task/internal/grpc/validation/requests"
+	"github.com/Citadelas/task/internal/lib/logger/sl"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// keepAliveInterval spaces the comments sent on idle event streams so
+// proxies do not drop them.
+const keepAliveInterval = 15 * time.Second
+
+var (
+	errInvalidSequence = errors.New("must be an event sequence number")
+	errStreamClosed    = errors.New("event stream closed")
+)
+
+type eventResponse struct {
+	Sequence  uint64              `json:"sequence,string"`
+	Type      string              `json:"type"`
+	TaskId    uint64              `json:"task_id,string"`
+	ActorId   uint64              `json:"actor_id,string"`
+	Changes   models.FieldChanges `json:"changes"`
+	Task      json.RawMessage     `json:"task"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+// watchTasks streams the changes to a user's tasks as server-sent events.
+// The event id is the sequence: browsers send it back in Last-Event-ID
+// when they reconnect, and other clients may pass it as ?after=, so the
+// stream resumes without missing changes.
+func (h *handler) watchTasks(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	requested, err := queryUint(r, "user_id")
+	if err != nil {
+		return err
+	}
+	uid := taskgrpc.UserId(ctx, requested)
+	if err := validation.ValidateStruct(ctx, requests.WatchTasksRequest{UID: uid}); err != nil {
+		return err
+	}
+	after, err := resumeAfter(r)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	stream := &eventStream{w: w, rc: http.NewResponseController(w)}
+	// The writer must not be touched once the handler returns, so the
+	// stream is closed to writes and its goroutines are waited for first.
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		stream.close()
+		wg.Wait()
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		select {
+		case <-h.shutdown:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := stream.flush(); err != nil {
+		return nil
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		stream.keepAlive(ctx, cancel)
+	}()
+
+	err = h.task.WatchTasks(ctx, uid, after, func(event *models.TaskEvent) error {
+		body, err := h.eventJSON(event)
+		if err != nil {
+			return err
+		}
+		return stream.send(event.Sequence, event.Type, body)
+	})
+	if err != nil && ctx.Err() == nil {
+		// Headers are out, so the failure can only end the stream.
+		h.log.ErrorContext(ctx, "task event stream failed", sl.Err(err))
+	}
+	return nil
+}
+
+func resumeAfter(r *http.Request) (uint64, error) {
+	raw := r.Header.Get("Last-Event-ID")
+	name := "Last-Event-ID"
+	if raw == "" {
+		raw, name = r.URL.Query().Get("after"), "after"
+	}
+	if raw == "" {
+		return 0, nil
+	}
+	after, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, invalidParam(name, errInvalidSequence)
+	}
+	return after, nil
+}
+
+func (h *handler) eventJSON(event *models.TaskEvent) ([]byte, error) {
+	res := eventResponse{
+		Sequence:  event.Sequence,
+		Type:      event.Type,
+		TaskId:    event.TaskId,
+		ActorId:   event.ActorId,
+		Changes:   event.Changes,
+		Task:      json.RawMessage("null"),
+		CreatedAt: event.CreatedAt,
+	}
+	if res.Changes == nil {
+		res.Changes = models.FieldChanges{}
+	}
+	if event.Task != nil {
+		var err error
+		if res.Task, err = h.taskJSON(event.Task); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(res)
+}
+
+// eventStream serializes the writes of events and keep-alive comments.
+// Once closed it refuses every write.
+type eventStream struct {
+	mu     sync.Mutex
+	w      http.ResponseWriter
+	rc     *http.ResponseController
+	closed bool
+}
+
+func (s *eventStream) send(id uint64, event string, data []byte) error {
+	return s.write(fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", id, event, data))
+}
+
+func (s *eventStream) flush() error {
+	return s.write("")
+}
+
+// write writes msg, if any, and flushes it to the client.
+func (s *eventStream) write(msg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errStreamClosed
+	}
+	if msg != "" {
+		if _, err := io.WriteString(s.w, msg); err != nil {
+			return err
+		}
+	}
+	return s.rc.Flush()
+}
+
+func (s *eventStream) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+}
+
+// keepAlive writes a comment every keepAliveInterval and cancels the watch
+// once the client is gone.
+func (s *eventStream) keepAlive(ctx context.Context, cancel context.CancelFunc) {
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := s.write(": keep-alive\n\n"); err != nil {
+			cancel()
+			return
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package taskhttp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"github.com/Citadelas/task/internal/domain/models"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// watchOnly serves WatchTasks from a function; other calls are not used.
+type watchOnly struct {
+	Task
+	watch func(ctx context.Context, uid, after uint64, send func(*models.TaskEvent) error) error
+}
+
+func (w watchOnly) WatchTasks(ctx context.Context, uid, after uint64, send func(*models.TaskEvent) error) error {
+	return w.watch(ctx, uid, after, send)
+}
+
+func newWatchServer(t *testing.T, task Task, shutdown chan struct{}) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	Register(mux, slog.New(slog.DiscardHandler), task, shutdown)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWatchTasksSendsAndResumes(t *testing.T) {
+	var gotAfter uint64
+	task := watchOnly{watch: func(ctx context.Context, uid, after uint64, send func(*models.TaskEvent) error) error {
+		gotAfter = after
+		return send(&models.TaskEvent{Sequence: after + 1, Type: models.ActionCreated, TaskId: 7, UserId: uid})
+	}}
+	srv := newWatchServer(t, task, make(chan struct{}))
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/v1/tasks/watch?user_id=3", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Last-Event-ID", "41")
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type = %q", ct)
+	}
+	var lines []string
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if gotAfter != 41 {
+		t.Errorf("resumed after %d, want 41", gotAfter)
+	}
+	if len(lines) < 3 || lines[0] != "id: 42" || lines[1] != "event: "+models.ActionCreated ||
+		!strings.Contains(lines[2], `"task_id":"7"`) {
+		t.Errorf("stream = %q", lines)
+	}
+}
+
+func TestWatchTasksEndsOnShutdown(t *testing.T) {
+	returned := make(chan struct{})
+	task := watchOnly{watch: func(ctx context.Context, uid, after uint64, send func(*models.TaskEvent) error) error {
+		defer close(returned)
+		<-ctx.Done()
+		return nil
+	}}
+	shutdown := make(chan struct{})
+	srv := newWatchServer(t, task, shutdown)
+
+	res, err := srv.Client().Get(srv.URL + "/v1/tasks/watch?user_id=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	close(shutdown)
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not end on shutdown")
+	}
+}
+
+func TestEventStreamRefusesWritesOnceClosed(t *testing.T) {
+	rec := httptest.NewRecorder()
+	stream := &eventStream{w: rec, rc: http.NewResponseController(rec)}
+	if err := stream.send(1, models.ActionCreated, []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	stream.close()
+	if err := stream.send(2, models.ActionCreated, []byte("{}")); !errors.Is(err, errStreamClosed) {
+		t.Errorf("send after close = %v, want %v", err, errStreamClosed)
+	}
+	if strings.Contains(rec.Body.String(), "id: 2") {
+		t.Errorf("event written after close: %q", rec.Body.String())
+	}
+}
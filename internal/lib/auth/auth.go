@@ -0,0 +1,17 @@
+package auth
+
+import "context"
+
+type ctxKey struct{}
+
+// NewContext returns a context carrying the id of the authenticated user.
+func NewContext(ctx context.Context, uid uint64) context.Context {
+	return context.WithValue(ctx, ctxKey{}, uid)
+}
+
+// UserIdFromContext returns the authenticated user, if the call has one.
+// Calls in trusted mode carry none.
+func UserIdFromContext(ctx context.Context) (uint64, bool) {
+	uid, ok := ctx.Value(ctxKey{}).(uint64)
+	return uid, ok
+}
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// writePublicKey stores the public half of key as PEM and returns the
+// file and its contents.
+func writePublicKey(t *testing.T, key *rsa.PrivateKey) (string, []byte) {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path, data
+}
+
+func writeJWKS(t *testing.T, kid string, key *rsa.PrivateKey) string {
+	t.Helper()
+	data, err := json.Marshal(map[string][]jwk{"keys": {{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func claims(subject string, expiresIn time.Duration) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Subject:   subject,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, claims jwt.Claims, key any) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func newVerifier(t *testing.T, cfg VerifierConfig) *Verifier {
+	t.Helper()
+	v, err := NewVerifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestVerifyHMAC(t *testing.T) {
+	v := newVerifier(t, VerifierConfig{HMACSecret: testSecret})
+	rsaKey := newRSAKey(t)
+	tests := map[string]struct {
+		token string
+		uid   uint64
+	}{
+		"valid": {token: sign(t, jwt.SigningMethodHS256, "", claims("42", time.Hour), []byte(testSecret)), uid: 42},
+		"expired": {token: sign(t, jwt.SigningMethodHS256, "", claims("42", -time.Minute),
+			[]byte(testSecret))},
+		"no expiry": {token: sign(t, jwt.SigningMethodHS256, "", jwt.RegisteredClaims{Subject: "42"},
+			[]byte(testSecret))},
+		"bad signature": {token: sign(t, jwt.SigningMethodHS256, "", claims("42", time.Hour),
+			[]byte("another secret"))},
+		"other alg": {token: sign(t, jwt.SigningMethodRS256, "", claims("42", time.Hour), rsaKey)},
+		"none alg": {token: sign(t, jwt.SigningMethodNone, "", claims("42", time.Hour),
+			jwt.UnsafeAllowNoneSignatureType)},
+		"no subject":          {token: sign(t, jwt.SigningMethodHS256, "", claims("", time.Hour), []byte(testSecret))},
+		"non-numeric subject": {token: sign(t, jwt.SigningMethodHS256, "", claims("alice", time.Hour), []byte(testSecret))},
+		"zero subject":        {token: sign(t, jwt.SigningMethodHS256, "", claims("0", time.Hour), []byte(testSecret))},
+		"garbage":             {token: "not.a.token"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			uid, err := v.Verify(tt.token)
+			if tt.uid != 0 {
+				if err != nil || uid != tt.uid {
+					t.Fatalf("Verify = %d, %v, want %d", uid, err, tt.uid)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("Verify = %d, %v, want %v", uid, err, ErrInvalidToken)
+			}
+		})
+	}
+}
+
+// An RS256 verifier must not accept HS256 tokens keyed with its public
+// key, which anyone can read.
+func TestVerifyRejectsAlgorithmConfusion(t *testing.T) {
+	key := newRSAKey(t)
+	path, publicPEM := writePublicKey(t, key)
+	v := newVerifier(t, VerifierConfig{PublicKeyFile: path})
+
+	if uid, err := v.Verify(sign(t, jwt.SigningMethodRS256, "", claims("7", time.Hour), key)); err != nil || uid != 7 {
+		t.Fatalf("RS256 token: Verify = %d, %v", uid, err)
+	}
+	forged := sign(t, jwt.SigningMethodHS256, "", claims("7", time.Hour), publicPEM)
+	if _, err := v.Verify(forged); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("HS256 token keyed with the public key: err = %v, want %v", err, ErrInvalidToken)
+	}
+	if _, err := v.Verify(sign(t, jwt.SigningMethodRS256, "", claims("7", time.Hour), newRSAKey(t))); err == nil {
+		t.Fatal("token signed by another key was accepted")
+	}
+}
+
+func TestVerifyJWKS(t *testing.T) {
+	key := newRSAKey(t)
+	v := newVerifier(t, VerifierConfig{JWKSFile: writeJWKS(t, "current", key), Issuer: "auth", Audience: "task"})
+	valid := claims("9", time.Hour)
+	valid.Issuer, valid.Audience = "auth", jwt.ClaimStrings{"task"}
+
+	if uid, err := v.Verify(sign(t, jwt.SigningMethodRS256, "current", valid, key)); err != nil || uid != 9 {
+		t.Fatalf("known kid: Verify = %d, %v", uid, err)
+	}
+	wrongAudience := valid
+	wrongAudience.Audience = jwt.ClaimStrings{"billing"}
+	rejected := map[string]string{
+		"unknown kid":    sign(t, jwt.SigningMethodRS256, "retired", valid, key),
+		"no kid":         sign(t, jwt.SigningMethodRS256, "", valid, key),
+		"wrong audience": sign(t, jwt.SigningMethodRS256, "current", wrongAudience, key),
+	}
+	for name, token := range rejected {
+		if _, err := v.Verify(token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: err = %v, want %v", name, err, ErrInvalidToken)
+		}
+	}
+}
+
+func TestNewVerifierNeedsKeys(t *testing.T) {
+	if _, err := NewVerifier(VerifierConfig{Issuer: "auth"}); !errors.Is(err, ErrNoKeys) {
+		t.Fatalf("NewVerifier = %v, want %v", err, ErrNoKeys)
+	}
+}
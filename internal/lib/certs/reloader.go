@@ -0,0 +1,159 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/Citadelas/task/internal/lib/logger/sl"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrNoCACerts = errors.New("no certificates found in CA bundle")
+
+// Reloader serves a certificate, and optionally a client CA bundle, read
+// from files. It checks the files every interval and swaps in the new
+// contents when they change, so certificates can be rotated without a
+// restart. Connections already established keep their certificate.
+type Reloader struct {
+	log      *slog.Logger
+	certFile string
+	keyFile  string
+	caFile   string
+	interval time.Duration
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  []time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New loads the files once. An empty caFile means client certificates are
+// not requested; otherwise they are required and verified against it.
+func New(log *slog.Logger, certFile, keyFile, caFile string, interval time.Duration) (*Reloader, error) {
+	const op = "certs.New"
+	r := &Reloader{
+		log:      log,
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if err := r.load(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return r, nil
+}
+
+// TLSConfig returns a server configuration that always uses the latest
+// certificate and CA bundle.
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*r.cert},
+			}
+			if r.clientCAs != nil {
+				cfg.ClientCAs = r.clientCAs
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+			return cfg, nil
+		},
+	}
+}
+
+// Run checks the files for changes every interval until Stop is called.
+func (r *Reloader) Run() {
+	const op = "certs.Reloader.Run"
+	log := r.log.With(slog.String("op", op))
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+		if !r.changed() {
+			continue
+		}
+		// A failed reload keeps serving the previous certificate, e.g.
+		// while a rotation has written the certificate but not yet the key.
+		if err := r.load(); err != nil {
+			log.Error("failed to reload certificates", sl.Err(err))
+			continue
+		}
+		log.Info("reloaded certificates")
+	}
+}
+
+func (r *Reloader) Stop() {
+	close(r.stop)
+	<-r.done
+}
+
+func (r *Reloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if r.caFile != "" {
+		files = append(files, r.caFile)
+	}
+	return files
+}
+
+func (r *Reloader) changed() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil || !info.ModTime().Equal(r.modTimes[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reloader) load() error {
+	var modTimes []time.Time
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		modTimes = append(modTimes, info.ModTime())
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	var clientCAs *x509.CertPool
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return err
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("%s: %w", r.caFile, ErrNoCACerts)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.modTimes = modTimes
+	return nil
+}
@@ -0,0 +1,239 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCert is a self-signed certificate for localhost in PEM form.
+type testCert struct {
+	leaf    *x509.Certificate
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newTestCert(t *testing.T, serial int64) testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testCert{
+		leaf:    leaf,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// writeFile writes data with a modification time of at, so a rewrite is
+// noticed even on file systems with a coarse clock.
+func writeFile(t *testing.T, path string, data []byte, at time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, at, at); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// serveTLS accepts connections with the reloader's configuration and
+// completes their handshakes.
+func serveTLS(t *testing.T, r *Reloader) string {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", r.TLSConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = conn.(*tls.Conn).Handshake()
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// handshake returns the leaf certificate the server presents, trusting
+// each of roots.
+func handshake(addr string, client *tls.Certificate, roots ...testCert) (*x509.Certificate, error) {
+	pool := x509.NewCertPool()
+	for _, root := range roots {
+		pool.AddCert(root.leaf)
+	}
+	cfg := &tls.Config{RootCAs: pool, ServerName: "localhost"}
+	if client != nil {
+		cfg.Certificates = []tls.Certificate{*client}
+	}
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: time.Second}, "tcp", addr, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	// The server rejects a missing client certificate only after the
+	// client has finished its side of a TLS 1.3 handshake.
+	if err := conn.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Read(make([]byte, 1)); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return conn.ConnectionState().PeerCertificates[0], nil
+}
+
+// awaitLeaf handshakes until the server presents want.
+func awaitLeaf(t *testing.T, addr string, want testCert, roots ...testCert) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		leaf, err := handshake(addr, nil, roots...)
+		if err == nil && leaf.Equal(want.leaf) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server did not present certificate %v: leaf %v, err %v", want.leaf.SerialNumber, leaf, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReloaderPresentsRotatedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	old, rotated := newTestCert(t, 1), newTestCert(t, 2)
+	start := time.Now().Add(-time.Hour)
+	writeFile(t, certFile, old.certPEM, start)
+	writeFile(t, keyFile, old.keyPEM, start)
+
+	r, err := New(slog.New(slog.DiscardHandler), certFile, keyFile, "", 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go r.Run()
+	t.Cleanup(r.Stop)
+	addr := serveTLS(t, r)
+	leaf, err := handshake(addr, nil, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !leaf.Equal(old.leaf) {
+		t.Fatalf("initial leaf = %v, want %v", leaf.SerialNumber, old.leaf.SerialNumber)
+	}
+
+	// A key that does not match the new certificate yet keeps the old pair
+	// in use.
+	writeFile(t, certFile, rotated.certPEM, start.Add(time.Minute))
+	time.Sleep(50 * time.Millisecond)
+	if leaf, err := handshake(addr, nil, old, rotated); err != nil || !leaf.Equal(old.leaf) {
+		t.Fatalf("leaf during rotation = %v, %v, want %v", leaf, err, old.leaf.SerialNumber)
+	}
+
+	writeFile(t, keyFile, rotated.keyPEM, start.Add(time.Minute))
+	awaitLeaf(t, addr, rotated, rotated)
+}
+
+func TestReloaderReloadsClientCAs(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	caFile := filepath.Join(dir, "ca.crt")
+	server, oldCA, newCA := newTestCert(t, 1), newTestCert(t, 2), newTestCert(t, 3)
+	start := time.Now().Add(-time.Hour)
+	writeFile(t, certFile, server.certPEM, start)
+	writeFile(t, keyFile, server.keyPEM, start)
+	writeFile(t, caFile, oldCA.certPEM, start)
+
+	r, err := New(slog.New(slog.DiscardHandler), certFile, keyFile, caFile, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go r.Run()
+	t.Cleanup(r.Stop)
+	addr := serveTLS(t, r)
+	clientCert := func(c testCert) *tls.Certificate {
+		pair, err := tls.X509KeyPair(c.certPEM, c.keyPEM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &pair
+	}
+
+	if _, err := handshake(addr, nil, server); err == nil {
+		t.Fatal("handshake without a client certificate succeeded")
+	}
+	if _, err := handshake(addr, clientCert(oldCA), server); err != nil {
+		t.Fatalf("handshake with a trusted client certificate: %v", err)
+	}
+
+	writeFile(t, caFile, newCA.certPEM, start.Add(time.Minute))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, errOld := handshake(addr, clientCert(oldCA), server)
+		_, errNew := handshake(addr, clientCert(newCA), server)
+		if errOld != nil && errNew == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("client CAs not reloaded: old CA %v, new CA %v", errOld, errNew)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNewRejectsEmptyCABundle(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	caFile := filepath.Join(dir, "ca.crt")
+	server := newTestCert(t, 1)
+	writeFile(t, certFile, server.certPEM, time.Now())
+	writeFile(t, keyFile, server.keyPEM, time.Now())
+	writeFile(t, caFile, []byte("not a certificate"), time.Now())
+
+	_, err := New(slog.New(slog.DiscardHandler), certFile, keyFile, caFile, time.Second)
+	if !errors.Is(err, ErrNoCACerts) {
+		t.Fatalf("New = %v, want %v", err, ErrNoCACerts)
+	}
+}
@@ -0,0 +1,50 @@
+package task
+
+import (
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage/storagetest"
+	"log/slog"
+	"testing"
+)
+
+type storageBackend interface {
+	TaskGetter
+	TaskCreator
+	TaskUpdater
+	TaskDeleter
+	TaskLister
+	TaskTrash
+	TaskHistory
+	TaskTags
+	TaskHierarchy
+	TaskDependencies
+	TaskSeries
+	Transactor
+}
+
+type testService struct {
+	name    string
+	service *Task
+}
+
+// testServices returns a service over each storage backend storagetest
+// opens, using the default workflow.
+func testServices(t *testing.T) []testService {
+	t.Helper()
+	workflow, err := models.NewWorkflow(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testServicesWithWorkflow(t, workflow)
+}
+
+func testServicesWithWorkflow(t *testing.T, workflow *models.Workflow) []testService {
+	t.Helper()
+	log := slog.New(slog.DiscardHandler)
+	var res []testService
+	for _, b := range storagetest.Backends[storageBackend](t) {
+		s := b.Storage
+		res = append(res, testService{name: b.Name, service: New(log, s, s, s, s, s, s, s, s, s, s, s, s, workflow)})
+	}
+	return res
+}
@@ -0,0 +1,156 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/lib/logger/sl"
+	"github.com/Citadelas/task/internal/lib/metrics"
+	"github.com/Citadelas/task/internal/lib/tracing"
+	"github.com/Citadelas/task/internal/storage"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrWrongBlockerId  = errors.New("wrong blocker id")
+	ErrDependencyCycle = errors.New("dependency would create a cycle")
+	ErrTaskBlocked     = errors.New("task is blocked")
+)
+
+// BlockedError reports the open tasks that keep a task from starting.
+type BlockedError struct {
+	BlockerIds []uint64
+}
+
+func (e *BlockedError) Error() string {
+	ids := make([]string, len(e.BlockerIds))
+	for i, id := range e.BlockerIds {
+		ids[i] = strconv.FormatUint(id, 10)
+	}
+	return fmt.Sprintf("%s by open tasks %s", ErrTaskBlocked, strings.Join(ids, ", "))
+}
+
+func (e *BlockedError) Unwrap() error {
+	return ErrTaskBlocked
+}
+
+type TaskDependencies interface {
+	AddDependency(ctx context.Context, id uint64, blockerId uint64) error
+	RemoveDependency(ctx context.Context, id uint64, blockerId uint64, uid uint64) error
+	ListBlockers(ctx context.Context, id uint64, uid uint64) ([]*models.Task, error)
+	ListTransitiveBlockers(ctx context.Context, id uint64) ([]uint64, error)
+}
+
+// AddBlocker records that blockerId must be done before id can start.
+func (t *Task) AddBlocker(ctx context.Context, id, blockerId, uid uint64) (err error) {
+	const op = "task.AddBlocker"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+	log := t.logger.With(
+		slog.String("op", op),
+	)
+	err = t.withinTx(ctx, func(ctx context.Context) error {
+		if _, err := t.getter.GetTask(ctx, id, uid); err != nil {
+			return err
+		}
+		if _, err := t.getter.GetTask(ctx, blockerId, uid); err != nil {
+			if errors.Is(err, storage.ErrTaskNotFound) {
+				return fmt.Errorf("%w: %d", ErrWrongBlockerId, blockerId)
+			}
+			return err
+		}
+		if id == blockerId {
+			return ErrDependencyCycle
+		}
+		chain, err := t.dependencies.ListTransitiveBlockers(ctx, blockerId)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(chain, id) {
+			return ErrDependencyCycle
+		}
+		return t.dependencies.AddDependency(ctx, id, blockerId)
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrTaskNotFound) {
+			log.WarnContext(ctx, "task not found", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, ErrWrongId)
+		}
+		if errors.Is(err, ErrWrongBlockerId) || errors.Is(err, ErrDependencyCycle) {
+			log.WarnContext(ctx, "invalid blocker", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		log.ErrorContext(ctx, "failed to add blocker", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (t *Task) RemoveBlocker(ctx context.Context, id, blockerId, uid uint64) (err error) {
+	const op = "task.RemoveBlocker"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+	log := t.logger.With(
+		slog.String("op", op),
+	)
+	if err := t.dependencies.RemoveDependency(ctx, id, blockerId, uid); err != nil {
+		if errors.Is(err, storage.ErrDependencyNotFound) {
+			log.WarnContext(ctx, "dependency not found", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, ErrWrongBlockerId)
+		}
+		log.ErrorContext(ctx, "failed to remove blocker", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ListBlockers returns the live tasks that block a task, done or not.
+func (t *Task) ListBlockers(ctx context.Context, id, uid uint64) (_ []*models.Task, err error) {
+	const op = "task.ListBlockers"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+	log := t.logger.With(
+		slog.String("op", op),
+	)
+	if _, err := t.getter.GetTask(ctx, id, uid); err != nil {
+		if errors.Is(err, storage.ErrTaskNotFound) {
+			log.WarnContext(ctx, "task not found", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, ErrWrongId)
+		}
+		log.ErrorContext(ctx, "failed to get task", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := t.dependencies.ListBlockers(ctx, id, uid)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to list blockers", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+// checkBlockers fails with a BlockedError when a task that blocks id is
+// still open. Trashed blockers are ignored.
+func (t *Task) checkBlockers(ctx context.Context, id, uid uint64) error {
+	blockers, err := t.dependencies.ListBlockers(ctx, id, uid)
+	if err != nil {
+		return err
+	}
+	var open []uint64
+	for _, blocker := range blockers {
+		if blocker.Status != models.StatusDone {
+			open = append(open, blocker.Id)
+		}
+	}
+	if len(open) > 0 {
+		return &BlockedError{BlockerIds: open}
+	}
+	return nil
+}
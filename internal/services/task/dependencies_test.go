@@ -0,0 +1,89 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage/storagetest"
+	"slices"
+	"testing"
+)
+
+func TestOpenBlockerKeepsTaskFromFinishing(t *testing.T) {
+	ctx := context.Background()
+	for _, backend := range testServices(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			s, uid := backend.service, storagetest.NewUserId()
+			task, err := s.CreateTask(ctx, uid, "task", "", models.PriorityLow, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			blocker, err := s.CreateTask(ctx, uid, "blocker", "", models.PriorityLow, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := s.AddBlocker(ctx, task.Id, blocker.Id, uid); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, status := range []models.Status{models.StatusInProgress, models.StatusDone} {
+				_, err := s.UpdateStatus(ctx, task.Id, uid, 0, status)
+				var blocked *BlockedError
+				if !errors.As(err, &blocked) || !slices.Equal(blocked.BlockerIds, []uint64{blocker.Id}) {
+					t.Fatalf("%s: err = %v, want blocked by %d", status, err, blocker.Id)
+				}
+			}
+			if got, err := s.GetTask(ctx, task.Id, uid); err != nil || got.Status != models.StatusTodo {
+				t.Fatalf("blocked task = %+v, %v", got, err)
+			}
+
+			if _, err := s.UpdateStatus(ctx, blocker.Id, uid, 0, models.StatusDone); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.UpdateStatus(ctx, task.Id, uid, 0, models.StatusDone); err != nil {
+				t.Fatalf("finish after blocker is done: %v", err)
+			}
+		})
+	}
+}
+
+func TestAddBlockerRejectsCycles(t *testing.T) {
+	ctx := context.Background()
+	for _, backend := range testServices(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			s, uid := backend.service, storagetest.NewUserId()
+			var chain []*models.Task
+			for _, title := range []string{"first", "second", "third"} {
+				task, err := s.CreateTask(ctx, uid, title, "", models.PriorityLow, nil, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				chain = append(chain, task)
+			}
+			// third waits for second, which waits for first.
+			for i := 1; i < len(chain); i++ {
+				if err := s.AddBlocker(ctx, chain[i].Id, chain[i-1].Id, uid); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cycles := map[string][2]uint64{
+				"self":       {chain[0].Id, chain[0].Id},
+				"direct":     {chain[0].Id, chain[1].Id},
+				"transitive": {chain[0].Id, chain[2].Id},
+			}
+			for name, edge := range cycles {
+				if err := s.AddBlocker(ctx, edge[0], edge[1], uid); !errors.Is(err, ErrDependencyCycle) {
+					t.Errorf("%s: err = %v, want %v", name, err, ErrDependencyCycle)
+				}
+			}
+			blockers, err := s.ListBlockers(ctx, chain[0].Id, uid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(blockers) != 0 {
+				t.Fatalf("rejected cycles added blockers %v", blockers)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/lib/logger/sl"
+	"github.com/Citadelas/task/internal/storage"
+	"log/slog"
+	"sync"
+)
+
+// watchBatchSize bounds the history read at once while a watcher catches up.
+const watchBatchSize = 100
+
+// broker wakes the watchers of a user when changes to the user's tasks
+// commit. Wake-ups carry no data and coalesce: a woken watcher reads
+// everything recorded since the last event it sent from task history.
+type broker struct {
+	mu   sync.Mutex
+	subs map[uint64]map[chan struct{}]struct{}
+}
+
+func newBroker() *broker {
+	return &broker{subs: make(map[uint64]map[chan struct{}]struct{})}
+}
+
+func (b *broker) subscribe(uid uint64) (<-chan struct{}, func()) {
+	wake := make(chan struct{}, 1)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs[uid] == nil {
+		b.subs[uid] = make(map[chan struct{}]struct{})
+	}
+	b.subs[uid][wake] = struct{}{}
+	return wake, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs[uid], wake)
+		if len(b.subs[uid]) == 0 {
+			delete(b.subs, uid)
+		}
+	}
+}
+
+func (b *broker) notify(uid uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for wake := range b.subs[uid] {
+		signal(wake)
+	}
+}
+
+func (b *broker) notifyAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, subs := range b.subs {
+		for wake := range subs {
+			signal(wake)
+		}
+	}
+}
+
+func signal(wake chan struct{}) {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+type changedUsersKey struct{}
+
+// changedUsers collects the owners of the tasks a transaction changed.
+type changedUsers map[uint64]struct{}
+
+// withinTx runs fn in a transaction and, once it commits, wakes the
+// watchers of every user whose tasks it recorded changes to. Nested calls
+// join the outer transaction and leave waking to it.
+func (t *Task) withinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(changedUsersKey{}).(changedUsers); ok {
+		return t.tx.WithinTx(ctx, fn)
+	}
+	changed := changedUsers{}
+	if err := t.tx.WithinTx(context.WithValue(ctx, changedUsersKey{}, changed), fn); err != nil {
+		return err
+	}
+	for uid := range changed {
+		t.events.notify(uid)
+	}
+	return nil
+}
+
+// Notify wakes the watchers of a user's tasks, for changes committed by
+// another instance sharing the storage.
+func (t *Task) Notify(uid uint64) {
+	t.events.notify(uid)
+}
+
+// NotifyAll wakes every watcher, e.g. after changes by other instances may
+// have gone unnoticed.
+func (t *Task) NotifyAll() {
+	t.events.notifyAll()
+}
+
+// WatchTasks calls send with every change to a user's tasks, in order, as
+// it commits. It starts after the event with sequence after, replaying the
+// ones recorded since, or with the next change when after is zero. It
+// returns nil when ctx ends and the error of a failed send.
+//
+// A watch lasts as long as its client stays connected, so unlike other
+// operations it is neither timed nor traced as a whole.
+func (t *Task) WatchTasks(ctx context.Context, uid, after uint64, send func(*models.TaskEvent) error) error {
+	const op = "task.WatchTasks"
+	log := t.logger.With(
+		slog.String("op", op),
+	)
+	// Subscribing first means a change committed while catching up still
+	// wakes the loop below.
+	wake, unsubscribe := t.events.subscribe(uid)
+	defer unsubscribe()
+	var err error
+	if after == 0 {
+		if after, err = t.history.LastHistoryId(ctx, uid); err != nil {
+			return t.watchError(ctx, log, op, err)
+		}
+	}
+	for {
+		entries, err := t.history.ListUserHistory(ctx, uid, after, watchBatchSize)
+		if err != nil {
+			return t.watchError(ctx, log, op, err)
+		}
+		for _, entry := range entries {
+			event, err := t.event(ctx, entry)
+			if err != nil {
+				return t.watchError(ctx, log, op, err)
+			}
+			if err := send(event); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+			after = entry.Id
+		}
+		if len(entries) == watchBatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-wake:
+		}
+	}
+}
+
+// watchError treats storage errors caused by the watcher leaving as the
+// end of the watch.
+func (t *Task) watchError(ctx context.Context, log *slog.Logger, op string, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	log.ErrorContext(ctx, "failed to read task events", sl.Err(err))
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+func (t *Task) event(ctx context.Context, entry *models.HistoryEntry) (*models.TaskEvent, error) {
+	event := &models.TaskEvent{
+		Sequence:  entry.Id,
+		Type:      entry.Action,
+		TaskId:    entry.TaskId,
+		UserId:    entry.UserId,
+		ActorId:   entry.ActorId,
+		Changes:   entry.Changes,
+		CreatedAt: entry.CreatedAt,
+	}
+	if entry.Action == models.ActionDeleted || entry.Action == models.ActionPurged {
+		return event, nil
+	}
+	task, err := t.getter.GetTask(ctx, entry.TaskId, entry.UserId)
+	if errors.Is(err, storage.ErrTaskNotFound) {
+		// Deleted by a later change, which has its own event.
+		return event, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	event.Task = task
+	return event, nil
+}
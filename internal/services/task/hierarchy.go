@@ -0,0 +1,169 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/lib/logger/sl"
+	"github.com/Citadelas/task/internal/lib/metrics"
+	"github.com/Citadelas/task/internal/lib/tracing"
+	"github.com/Citadelas/task/internal/storage"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+var (
+	ErrWrongParentId  = errors.New("wrong parent id")
+	ErrHierarchyCycle = errors.New("task cannot be moved under itself or its subtasks")
+)
+
+type TaskHierarchy interface {
+	MoveTask(ctx context.Context, id uint64, uid uint64, version uint64, parentId *uint64) (*models.Task, error)
+	ListSubtree(ctx context.Context, id uint64, uid uint64) ([]*models.Task, error)
+}
+
+func (t *Task) CreateSubtask(ctx context.Context, parentId, uid uint64, title, description string,
+	priority models.Priority, dueDate *time.Time, tags []string) (_ *models.Task, err error) {
+	const op = "task.CreateSubtask"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+	log := t.logger.With(
+		slog.String("op", op),
+	)
+	tags, err = normalizeTags(tags)
+	if err != nil {
+		log.WarnContext(ctx, "invalid tags", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	var res *models.Task
+	err = t.withinTx(ctx, func(ctx context.Context) error {
+		if err := t.checkParent(ctx, parentId, uid); err != nil {
+			return err
+		}
+		var err error
+		res, err = t.create(ctx, uid, title, description, priority, dueDate, tags, &parentId)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, ErrWrongParentId) {
+			log.WarnContext(ctx, "parent task not found", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		log.ErrorContext(ctx, "Failed to create subtask", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+// MoveTask places a task under a new parent, or at the top level when
+// parentId is nil. A task cannot be moved under itself or its subtasks.
+func (t *Task) MoveTask(ctx context.Context, id, uid, version uint64, parentId *uint64) (_ *models.Task, err error) {
+	const op = "task.MoveTask"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+	log := t.logger.With(
+		slog.String("op", op),
+	)
+	var res *models.Task
+	err = t.withinTx(ctx, func(ctx context.Context) error {
+		before, err := t.getter.GetTask(ctx, id, uid)
+		if err != nil {
+			return err
+		}
+		if parentId != nil {
+			if err := t.checkParent(ctx, *parentId, uid); err != nil {
+				return err
+			}
+			subtree, err := t.tree.ListSubtree(ctx, id, uid)
+			if err != nil {
+				return err
+			}
+			if slices.ContainsFunc(subtree, func(task *models.Task) bool { return task.Id == *parentId }) {
+				return ErrHierarchyCycle
+			}
+		}
+		res, err = t.tree.MoveTask(ctx, id, uid, version, parentId)
+		if err != nil {
+			return err
+		}
+		return t.record(ctx, res, models.ActionUpdated, models.DiffTasks(before, res))
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrTaskNotFound) {
+			log.WarnContext(ctx, "task not found", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, ErrWrongId)
+		}
+		if errors.Is(err, storage.ErrVersionMismatch) {
+			log.WarnContext(ctx, "version conflict", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, ErrVersionConflict)
+		}
+		if errors.Is(err, ErrWrongParentId) || errors.Is(err, ErrHierarchyCycle) {
+			log.WarnContext(ctx, "invalid parent", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		log.ErrorContext(ctx, "failed to move task", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+// GetSubtree returns a task with all of its subtasks and their completion
+// progress.
+func (t *Task) GetSubtree(ctx context.Context, id, uid uint64) (_ *models.TaskTree, err error) {
+	const op = "task.GetSubtree"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+	log := t.logger.With(
+		slog.String("op", op),
+	)
+	tasks, err := t.tree.ListSubtree(ctx, id, uid)
+	if err != nil {
+		if errors.Is(err, storage.ErrTaskNotFound) {
+			log.WarnContext(ctx, "task not found", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, ErrWrongId)
+		}
+		log.ErrorContext(ctx, "failed to get subtree", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return models.BuildTree(id, tasks), nil
+}
+
+// checkParent makes sure the parent is a live task of the same user. Inside
+// a postgres transaction this also locks the parent row, so concurrent moves
+// cannot build a cycle.
+func (t *Task) checkParent(ctx context.Context, parentId, uid uint64) error {
+	if _, err := t.getter.GetTask(ctx, parentId, uid); err != nil {
+		if errors.Is(err, storage.ErrTaskNotFound) {
+			return fmt.Errorf("%w: %d", ErrWrongParentId, parentId)
+		}
+		return err
+	}
+	return nil
+}
+
+// reparentChildren moves the direct subtasks of task up to its parent and
+// records the change for each of them.
+func (t *Task) reparentChildren(ctx context.Context, task *models.Task) error {
+	subtree, err := t.tree.ListSubtree(ctx, task.Id, task.UserId)
+	if err != nil {
+		return err
+	}
+	for _, child := range subtree[1:] {
+		if child.ParentId == nil || *child.ParentId != task.Id {
+			continue
+		}
+		moved, err := t.tree.MoveTask(ctx, child.Id, child.UserId, 0, task.ParentId)
+		if err != nil {
+			return err
+		}
+		if err := t.record(ctx, moved, models.ActionUpdated, models.DiffTasks(child, moved)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage/storagetest"
+	"slices"
+	"testing"
+)
+
+func TestMoveTaskRejectsCycles(t *testing.T) {
+	ctx := context.Background()
+	for _, backend := range testServices(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			s, uid := backend.service, storagetest.NewUserId()
+			root, err := s.CreateTask(ctx, uid, "root", "", models.PriorityLow, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			child, err := s.CreateSubtask(ctx, root.Id, uid, "child", "", models.PriorityLow, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			grandchild, err := s.CreateSubtask(ctx, child.Id, uid, "grandchild", "", models.PriorityLow, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for name, parent := range map[string]uint64{
+				"itself":     root.Id,
+				"child":      child.Id,
+				"grandchild": grandchild.Id,
+			} {
+				if _, err := s.MoveTask(ctx, root.Id, uid, 0, &parent); !errors.Is(err, ErrHierarchyCycle) {
+					t.Errorf("under %s: err = %v, want %v", name, err, ErrHierarchyCycle)
+				}
+			}
+			if got, err := s.GetTask(ctx, root.Id, uid); err != nil || got.ParentId != nil {
+				t.Fatalf("rejected moves changed the root: %+v, %v", got, err)
+			}
+
+			// Moving the grandchild up is fine.
+			moved, err := s.MoveTask(ctx, grandchild.Id, uid, 0, &root.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if moved.ParentId == nil || *moved.ParentId != root.Id {
+				t.Fatalf("moved parent = %v, want %d", moved.ParentId, root.Id)
+			}
+		})
+	}
+}
+
+func TestMoveTaskStaysWithinUser(t *testing.T) {
+	ctx := context.Background()
+	for _, backend := range testServices(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			s := backend.service
+			owner, other := storagetest.NewUserId(), storagetest.NewUserId()
+			task, err := s.CreateTask(ctx, owner, "task", "", models.PriorityLow, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			foreign, err := s.CreateTask(ctx, other, "foreign", "", models.PriorityLow, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := s.MoveTask(ctx, task.Id, owner, 0, &foreign.Id); !errors.Is(err, ErrWrongParentId) {
+				t.Errorf("under a task of another user: err = %v, want %v", err, ErrWrongParentId)
+			}
+			if _, err := s.MoveTask(ctx, foreign.Id, owner, 0, &task.Id); !errors.Is(err, ErrWrongId) {
+				t.Errorf("task of another user: err = %v, want %v", err, ErrWrongId)
+			}
+			_, err = s.CreateSubtask(ctx, foreign.Id, owner, "sub", "", models.PriorityLow, nil, nil)
+			if !errors.Is(err, ErrWrongParentId) {
+				t.Errorf("subtask of another user's task: err = %v, want %v", err, ErrWrongParentId)
+			}
+			for _, id := range []uint64{task.Id, foreign.Id} {
+				uid := owner
+				if id == foreign.Id {
+					uid = other
+				}
+				if got, err := s.GetTask(ctx, id, uid); err != nil || got.ParentId != nil {
+					t.Fatalf("rejected moves changed task %d: %+v, %v", id, got, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSubtreeOrderAndProgress(t *testing.T) {
+	ctx := context.Background()
+	for _, backend := range testServices(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			s, uid := backend.service, storagetest.NewUserId()
+			create := func(parent *models.Task, title string) *models.Task {
+				t.Helper()
+				var task *models.Task
+				var err error
+				if parent == nil {
+					task, err = s.CreateTask(ctx, uid, title, "", models.PriorityLow, nil, nil)
+				} else {
+					task, err = s.CreateSubtask(ctx, parent.Id, uid, title, "", models.PriorityLow, nil, nil)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				return task
+			}
+			finish := func(task *models.Task, status models.Status) {
+				t.Helper()
+				if _, err := s.UpdateStatus(ctx, task.Id, uid, 0, status); err != nil {
+					t.Fatal(err)
+				}
+			}
+			root := create(nil, "root")
+			first := create(root, "first")
+			second := create(root, "second")
+			third := create(root, "third")
+			done := create(first, "done")
+			started := create(first, "started")
+			create(first, "todo")
+			finish(done, models.StatusDone)
+			finish(started, models.StatusInProgress)
+			finish(second, models.StatusDone)
+			finish(third, models.StatusInProgress)
+
+			tree, err := s.GetSubtree(ctx, root.Id, uid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			titles := func(nodes []*models.TaskTree) []string {
+				var res []string
+				for _, node := range nodes {
+					res = append(res, node.Task.Title)
+				}
+				return res
+			}
+			if got := titles(tree.Children); !slices.Equal(got, []string{"first", "second", "third"}) {
+				t.Fatalf("children = %v, want [first second third]", got)
+			}
+			if got := titles(tree.Children[0].Children); !slices.Equal(got, []string{"done", "started", "todo"}) {
+				t.Fatalf("grandchildren = %v, want [done started todo]", got)
+			}
+			// first: one of three done is 33; second: 100; third: 0.
+			wants := []int{33, 100, 0}
+			for i, child := range tree.Children {
+				if child.Progress != wants[i] {
+					t.Errorf("progress of %s = %d, want %d", child.Task.Title, child.Progress, wants[i])
+				}
+			}
+			if tree.Progress != 44 {
+				t.Errorf("progress of root = %d, want 44", tree.Progress)
+			}
+		})
+	}
+}
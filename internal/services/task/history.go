@@ -0,0 +1,60 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/lib/auth"
+	"github.com/Citadelas/task/internal/lib/logger/sl"
+	"github.com/Citadelas/task/internal/lib/metrics"
+	"github.com/Citadelas/task/internal/lib/tracing"
+	"github.com/Citadelas/task/internal/storage"
+	"log/slog"
+	"time"
+)
+
+// GetTaskHistory pages through the changes made to a task, oldest first.
+func (t *Task) GetTaskHistory(ctx context.Context, id, uid uint64,
+	pageSize int, pageToken string) (_ *models.HistoryPage, err error) {
+	const op = "task.GetTaskHistory"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+	log := t.logger.With(
+		slog.String("op", op),
+	)
+	res, err := t.history.ListHistory(ctx, id, uid, clampPageSize(pageSize), pageToken)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidCursor) {
+			log.WarnContext(ctx, "invalid page token", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, ErrInvalidPageToken)
+		}
+		log.ErrorContext(ctx, "failed to list task history", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+// record appends a history entry for task. It must be called inside the
+// transaction that made the change, started with withinTx, which wakes the
+// owner's watchers once it commits.
+func (t *Task) record(ctx context.Context, task *models.Task, action string, changes models.FieldChanges) error {
+	if changed, ok := ctx.Value(changedUsersKey{}).(changedUsers); ok {
+		changed[task.UserId] = struct{}{}
+	}
+	// The actor is the authenticated caller; without one (trusted mode, or
+	// the background purger) changes are attributed to the owner.
+	actor, ok := auth.UserIdFromContext(ctx)
+	if !ok {
+		actor = task.UserId
+	}
+	_, err := t.history.AddHistory(ctx, models.HistoryEntry{
+		TaskId:  task.Id,
+		UserId:  task.UserId,
+		ActorId: actor,
+		Action:  action,
+		Changes: changes,
+	})
+	return err
+}
@@ -0,0 +1,75 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage/storagetest"
+	"slices"
+	"testing"
+)
+
+func TestListTasksTagFilterParity(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		tags  []string
+		match models.TagMatch
+		want  []string
+	}{
+		{name: "all, duplicate", tags: []string{"home", "home"}, match: models.TagMatchAll, want: []string{"both", "home"}},
+		{name: "all, case and space", tags: []string{"Home", " home "}, match: models.TagMatchAll,
+			want: []string{"both", "home"}},
+		{name: "all, two tags", tags: []string{"home", "work", "HOME"}, match: models.TagMatchAll, want: []string{"both"}},
+		{name: "any, duplicate", tags: []string{"work", "Work"}, match: models.TagMatchAny, want: []string{"both", "work"}},
+		{name: "any, two tags", tags: []string{"home", "work"}, match: models.TagMatchAny,
+			want: []string{"both", "home", "work"}},
+		{name: "no tags", match: models.TagMatchAll, want: []string{"both", "home", "none", "work"}},
+	}
+	for _, backend := range testServices(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			uid := storagetest.NewUserId()
+			for title, tags := range map[string][]string{
+				"both": {"home", "work"},
+				"home": {"home"},
+				"work": {"work"},
+				"none": nil,
+			} {
+				if _, err := backend.service.CreateTask(ctx, uid, title, "", models.PriorityLow, nil, tags); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					page, err := backend.service.ListTasks(ctx, models.ListTasksQuery{
+						UserId: uid,
+						Filter: models.TaskFilter{Tags: tt.tags, TagMatch: tt.match},
+					})
+					if err != nil {
+						t.Fatal(err)
+					}
+					var got []string
+					for _, task := range page.Tasks {
+						got = append(got, task.Title)
+					}
+					slices.Sort(got)
+					if !slices.Equal(got, tt.want) {
+						t.Errorf("tasks = %v, want %v", got, tt.want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestListTasksInvalidTag(t *testing.T) {
+	for _, backend := range testServices(t) {
+		_, err := backend.service.ListTasks(context.Background(), models.ListTasksQuery{
+			UserId: storagetest.NewUserId(),
+			Filter: models.TaskFilter{Tags: []string{"home", " "}, TagMatch: models.TagMatchAll},
+		})
+		if !errors.Is(err, ErrInvalidTag) {
+			t.Errorf("%s: error = %v, want %v", backend.name, err, ErrInvalidTag)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/lib/logger/sl"
+	"github.com/Citadelas/task/internal/lib/metrics"
+	"github.com/Citadelas/task/internal/lib/tracing"
+	"github.com/Citadelas/task/internal/storage"
+	"log/slog"
+	"time"
+)
+
+var (
+	ErrInvalidRecurrence = errors.New("invalid recurrence")
+	ErrWrongSeriesId     = errors.New("wrong series id")
+)
+
+type TaskSeries interface {
+	CreateSeries(ctx context.Context, uid uint64, rule string, startsAt time.Time) (*models.Series, error)
+	GetSeries(ctx context.Context, id uint64, uid uint64) (*models.Series, error)
+	UpdateSeries(ctx context.Context, id uint64, uid uint64, rule string, stopped bool) (*models.Series, error)
+	SetOccurrence(ctx context.Context, id uint64, uid uint64, seriesId uint64, occurrence int) (*models.Task, error)
+	OccurrenceExists(ctx context.Context, seriesId uint64, occurrence int) (bool, error)
+}
+
+// MakeRecurring starts a series with the task as its first occurrence. The
+// task needs a due date, which anchors the schedule of the rule. Each time
+// an occurrence is marked done UpdateStatus spawns the next one.
+func (t *Task) MakeRecurring(ctx context.Context, id, uid uint64, rule string) (_ *models.Task, err error) {
+	const op = "task.MakeRecurring"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+	log := t.logger.With(
+		slog.String("op", op),
+	)
+	recurrence, err := models.ParseRule(rule)
+	if err != nil {
+		log.WarnContext(ctx, "invalid recurrence rule", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidRecurrence, err)
+	}
+	var res *models.Task
+	err = t.withinTx(ctx, func(ctx context.Context) error {
+		task, err := t.getter.GetTask(ctx, id, uid)
+		if err != nil {
+			return err
+		}
+		if task.DueDate == nil {
+			return fmt.Errorf("%w: task has no due date", ErrInvalidRecurrence)
+		}
+		if task.SeriesId != nil {
+			return fmt.Errorf("%w: task already recurs", ErrInvalidRecurrence)
+		}
+		series, err := t.series.CreateSeries(ctx, uid, recurrence.String(), *task.DueDate)
+		if err != nil {
+			return err
+		}
+		res, err = t.series.SetOccurrence(ctx, id, uid, series.Id, 1)
+		if err != nil {
+			return err
+		}
+		return t.record(ctx, res, models.ActionUpdated, models.DiffTasks(task, res))
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrTaskNotFound) {
+			log.WarnContext(ctx, "task not found", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, ErrWrongId)
+		}
+		if errors.Is(err, ErrInvalidRecurrence) {
+			log.WarnContext(ctx, "task cannot recur", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		log.ErrorContext(ctx, "failed to make task recurring", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+func (t *Task) GetSeries(ctx context.Context, id, uid uint64) (_ *models.Series, err error) {
+	const op = "task.GetSeries"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+	log := t.logger.With(
+		slog.String("op", op),
+	)
+	res, err := t.series.GetSeries(ctx, id, uid)
+	if err != nil {
+		if errors.Is(err, storage.ErrSeriesNotFound) {
+			log.WarnContext(ctx, "series not found", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, ErrWrongSeriesId)
+		}
+		log.ErrorContext(ctx, "failed to get series", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+// UpdateSeries replaces the rule of a series. It applies from the next
+// occurrence on; existing occurrences keep their due dates.
+func (t *Task) UpdateSeries(ctx context.Context, id, uid uint64, rule string) (_ *models.Series, err error) {
+	const op = "task.UpdateSeries"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+	log := t.logger.With(
+		slog.String("op", op),
+	)
+	recurrence, err := models.ParseRule(rule)
+	if err != nil {
+		log.WarnContext(ctx, "invalid recurrence rule", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidRecurrence, err)
+	}
+	res, err := t.setSeries(ctx, id, uid, func(series *models.Series) {
+		series.Rule = recurrence.String()
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrSeriesNotFound) {
+			log.WarnContext(ctx, "series not found", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, ErrWrongSeriesId)
+		}
+		log.ErrorContext(ctx, "failed to update series", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+// StopSeries keeps a series from spawning further occurrences.
+func (t *Task) StopSeries(ctx context.Context, id, uid uint64) (_ *models.Series, err error) {
+	const op = "task.StopSeries"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+	log := t.logger.With(
+		slog.String("op", op),
+	)
+	res, err := t.setSeries(ctx, id, uid, func(series *models.Series) {
+		series.Stopped = true
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrSeriesNotFound) {
+			log.WarnContext(ctx, "series not found", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, ErrWrongSeriesId)
+		}
+		log.ErrorContext(ctx, "failed to stop series", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+func (t *Task) setSeries(ctx context.Context, id, uid uint64, change func(*models.Series)) (*models.Series, error) {
+	var res *models.Series
+	err := t.withinTx(ctx, func(ctx context.Context) error {
+		series, err := t.series.GetSeries(ctx, id, uid)
+		if err != nil {
+			return err
+		}
+		change(series)
+		res, err = t.series.UpdateSeries(ctx, id, uid, series.Rule, series.Stopped)
+		return err
+	})
+	return res, err
+}
+
+// spawnNext creates the occurrence that follows a task which was just marked
+// done. Nothing is created when the series is stopped, its COUNT or UNTIL is
+// exhausted, or the next occurrence already exists because the task was
+// reopened and finished again. It runs in the caller's transaction, and the
+// creation is recorded once the task has joined the series.
+func (t *Task) spawnNext(ctx context.Context, task *models.Task) error {
+	series, err := t.series.GetSeries(ctx, *task.SeriesId, task.UserId)
+	if err != nil || series.Stopped {
+		return err
+	}
+	recurrence, err := models.ParseRule(series.Rule)
+	if err != nil {
+		return err
+	}
+	occurrence := task.Occurrence + 1
+	if recurrence.Count > 0 && occurrence > recurrence.Count {
+		return nil
+	}
+	exists, err := t.series.OccurrenceExists(ctx, series.Id, occurrence)
+	if err != nil || exists {
+		return err
+	}
+	after := time.Now()
+	if task.DueDate != nil {
+		after = *task.DueDate
+	}
+	dueDate, ok := recurrence.Next(series.StartsAt, after)
+	if !ok {
+		return nil
+	}
+	next, err := t.insert(ctx, task.UserId, task.Title, task.Description, task.Priority, &dueDate,
+		task.Tags, task.ParentId)
+	if err != nil {
+		return err
+	}
+	next, err = t.series.SetOccurrence(ctx, next.Id, next.UserId, series.Id, occurrence)
+	if err != nil {
+		return err
+	}
+	return t.record(ctx, next, models.ActionCreated, models.DiffTasks(nil, next))
+}
@@ -0,0 +1,91 @@
+package task
+
+import (
+	"context"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage/storagetest"
+	"testing"
+	"time"
+)
+
+func TestRecurrenceIsRecordedAndNotified(t *testing.T) {
+	ctx := context.Background()
+	due := time.Date(2031, time.January, 6, 9, 0, 0, 0, time.UTC)
+	for _, backend := range testServices(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			s, uid := backend.service, storagetest.NewUserId()
+			wake, unsubscribe := s.events.subscribe(uid)
+			defer unsubscribe()
+			first, err := s.CreateTask(ctx, uid, "standup", "", models.PriorityLow, &due, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectWake(t, wake, "CreateTask")
+
+			first, err = s.MakeRecurring(ctx, first.Id, uid, "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=2")
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectWake(t, wake, "MakeRecurring")
+			if _, err := s.UpdateStatus(ctx, first.Id, uid, 0, models.StatusDone); err != nil {
+				t.Fatal(err)
+			}
+			expectWake(t, wake, "UpdateStatus")
+
+			entries, err := s.history.ListUserHistory(ctx, uid, 0, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 4 {
+				t.Fatalf("history has %d entries, want 4", len(entries))
+			}
+			seriesId := changeTo(entries[1].Changes, models.FieldSeries)
+			if entries[1].TaskId != first.Id || entries[1].Action != models.ActionUpdated || seriesId == "" ||
+				changeTo(entries[1].Changes, models.FieldOccurrence) != "1" {
+				t.Errorf("MakeRecurring recorded %+v", entries[1])
+			}
+			if entries[2].TaskId != first.Id || entries[2].Action != models.ActionStatusChanged {
+				t.Errorf("UpdateStatus recorded %+v", entries[2])
+			}
+			next := entries[3]
+			if next.Action != models.ActionCreated || next.TaskId == first.Id ||
+				changeTo(next.Changes, models.FieldSeries) != seriesId ||
+				changeTo(next.Changes, models.FieldOccurrence) != "2" ||
+				changeTo(next.Changes, models.FieldDueDate) != "2031-01-09T09:00:00Z" {
+				t.Errorf("next occurrence recorded %+v", next)
+			}
+
+			// COUNT=2 ends the series with the second occurrence.
+			if _, err := s.UpdateStatus(ctx, next.TaskId, uid, 0, models.StatusDone); err != nil {
+				t.Fatal(err)
+			}
+			entries, err = s.history.ListUserHistory(ctx, uid, next.Id, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 || entries[0].Action != models.ActionStatusChanged {
+				t.Errorf("history after the last occurrence = %+v", entries)
+			}
+		})
+	}
+}
+
+func expectWake(t *testing.T, wake <-chan struct{}, op string) {
+	t.Helper()
+	select {
+	case <-wake:
+	default:
+		t.Errorf("%s did not wake the watchers", op)
+	}
+}
+
+// changeTo returns the new value of field in changes, or "" when the field
+// did not change or was unset.
+func changeTo(changes models.FieldChanges, field string) string {
+	for _, change := range changes {
+		if change.Field == field && change.After != nil {
+			return *change.After
+		}
+	}
+	return ""
+}
@@ -0,0 +1,162 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/lib/logger/sl"
+	"github.com/Citadelas/task/internal/lib/metrics"
+	"github.com/Citadelas/task/internal/lib/tracing"
+	"github.com/Citadelas/task/internal/storage"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidTag = errors.New("invalid tag name")
+	ErrTagExists  = errors.New("tag already exists")
+	ErrWrongTagId = errors.New("wrong tag id")
+)
+
+type TaskTags interface {
+	CreateTag(ctx context.Context, uid uint64, name string) (*models.Tag, error)
+	ListTags(ctx context.Context, uid uint64) ([]*models.Tag, error)
+	RenameTag(ctx context.Context, id uint64, uid uint64, name string) (*models.Tag, error)
+	DeleteTag(ctx context.Context, id uint64, uid uint64) error
+	AddTaskTags(ctx context.Context, id uint64, uid uint64, names []string) error
+	RemoveTaskTags(ctx context.Context, id uint64, uid uint64, names []string) error
+}
+
+func (t *Task) CreateTag(ctx context.Context, uid uint64, name string) (_ *models.Tag, err error) {
+	const op = "task.CreateTag"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+	log := t.logger.With(
+		slog.String("op", op),
+	)
+	name, err = normalizeTag(name)
+	if err != nil {
+		log.WarnContext(ctx, "invalid tag", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := t.tags.CreateTag(ctx, uid, name)
+	if err != nil {
+		if errors.Is(err, storage.ErrTagExists) {
+			log.WarnContext(ctx, "tag already exists", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, ErrTagExists)
+		}
+		log.ErrorContext(ctx, "failed to create tag", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+func (t *Task) ListTags(ctx context.Context, uid uint64) (_ []*models.Tag, err error) {
+	const op = "task.ListTags"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+	log := t.logger.With(
+		slog.String("op", op),
+	)
+	res, err := t.tags.ListTags(ctx, uid)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to list tags", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+// RenameTag renames a tag on every task that carries it.
+func (t *Task) RenameTag(ctx context.Context, id, uid uint64, name string) (_ *models.Tag, err error) {
+	const op = "task.RenameTag"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+	log := t.logger.With(
+		slog.String("op", op),
+	)
+	name, err = normalizeTag(name)
+	if err != nil {
+		log.WarnContext(ctx, "invalid tag", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := t.tags.RenameTag(ctx, id, uid, name)
+	if err != nil {
+		if errors.Is(err, storage.ErrTagNotFound) {
+			log.WarnContext(ctx, "tag not found", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, ErrWrongTagId)
+		}
+		if errors.Is(err, storage.ErrTagExists) {
+			log.WarnContext(ctx, "tag already exists", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, ErrTagExists)
+		}
+		log.ErrorContext(ctx, "failed to rename tag", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+// DeleteTag removes a tag and detaches it from every task. Renaming and
+// deleting tags does not bump task versions or record task history.
+func (t *Task) DeleteTag(ctx context.Context, id, uid uint64) (err error) {
+	const op = "task.DeleteTag"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+	log := t.logger.With(
+		slog.String("op", op),
+	)
+	if err := t.tags.DeleteTag(ctx, id, uid); err != nil {
+		if errors.Is(err, storage.ErrTagNotFound) {
+			log.WarnContext(ctx, "tag not found", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, ErrWrongTagId)
+		}
+		log.ErrorContext(ctx, "failed to delete tag", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// applyTags attaches and detaches the tags of an update and returns the
+// task as it is afterwards.
+func (t *Task) applyTags(ctx context.Context, id, uid uint64, update models.TaskUpdate) (*models.Task, error) {
+	if len(update.AddTags) > 0 {
+		if err := t.tags.AddTaskTags(ctx, id, uid, update.AddTags); err != nil {
+			return nil, err
+		}
+	}
+	if len(update.RemoveTags) > 0 {
+		if err := t.tags.RemoveTaskTags(ctx, id, uid, update.RemoveTags); err != nil {
+			return nil, err
+		}
+	}
+	return t.getter.GetTask(ctx, id, uid)
+}
+
+// normalizeTags trims and lowercases tag names and drops duplicates.
+func normalizeTags(names []string) ([]string, error) {
+	var res []string
+	for _, name := range names {
+		name, err := normalizeTag(name)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(res, name) {
+			res = append(res, name)
+		}
+	}
+	return res, nil
+}
+
+func normalizeTag(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", fmt.Errorf("%w: empty name", ErrInvalidTag)
+	}
+	return name, nil
+}
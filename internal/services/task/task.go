@@ -1,43 +1,89 @@
 package task
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"github.com/Citadelas/task/internal/domain/models"
 	"github.com/Citadelas/task/internal/lib/logger/sl"
+	"github.com/Citadelas/task/internal/lib/metrics"
+	"github.com/Citadelas/task/internal/lib/tracing"
 	"github.com/Citadelas/task/internal/storage"
 	"log/slog"
+	"slices"
+	"time"
 )
 
 type Task struct {
-	logger  *slog.Logger
-	getter  TaskGetter
-	creator TaskCreator
-	updater TaskUpdater
-	deleter TaskDeleter
+	logger       *slog.Logger
+	getter       TaskGetter
+	creator      TaskCreator
+	updater      TaskUpdater
+	deleter      TaskDeleter
+	lister       TaskLister
+	trash        TaskTrash
+	history      TaskHistory
+	tags         TaskTags
+	tree         TaskHierarchy
+	dependencies TaskDependencies
+	series       TaskSeries
+	tx           Transactor
+	workflow     *models.Workflow
+	events       *broker
 }
 
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
 var (
-	ErrWrongId = errors.New("wrong id")
+	ErrWrongId          = errors.New("wrong id")
+	ErrInvalidPageToken = errors.New("invalid page token")
+	ErrInvalidMask      = errors.New("invalid update mask")
+	ErrVersionConflict  = errors.New("task was modified concurrently")
 )
 
 type TaskCreator interface {
 	CreateTask(ctx context.Context, uid uint64, title, description string,
-		priority string) (*models.Task, error)
+		priority models.Priority, dueDate *time.Time) (*models.Task, error)
 }
 
 type TaskGetter interface {
 	GetTask(ctx context.Context, id uint64, uid uint64) (*models.Task, error)
 }
 type TaskUpdater interface {
-	UpdateTask(ctx context.Context, id uint64, uid uint64, title, description string,
-		priority string) (*models.Task, error)
-	UpdateStatus(ctx context.Context, id uint64, uid uint64, status string) (*models.Task, error)
+	UpdateTask(ctx context.Context, id uint64, uid uint64, version uint64,
+		update models.TaskUpdate) (*models.Task, error)
+	UpdateStatus(ctx context.Context, id uint64, uid uint64, version uint64, status models.Status) (*models.Task, error)
 }
 
 type TaskDeleter interface {
-	DeleteTask(ctx context.Context, id uint64, uid uint64) error
+	DeleteTask(ctx context.Context, id uint64, uid uint64, version uint64) error
+}
+
+type TaskLister interface {
+	ListTasks(ctx context.Context, query models.ListTasksQuery) (*models.TaskPage, error)
+}
+
+// Transactor runs fn atomically; storage calls made with the context
+// passed to fn take part in the same transaction.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type TaskHistory interface {
+	AddHistory(ctx context.Context, entry models.HistoryEntry) (*models.HistoryEntry, error)
+	ListHistory(ctx context.Context, id uint64, uid uint64, pageSize int, pageToken string) (*models.HistoryPage, error)
+	ListUserHistory(ctx context.Context, uid uint64, after uint64, limit int) ([]*models.HistoryEntry, error)
+	LastHistoryId(ctx context.Context, uid uint64) (uint64, error)
+}
+
+type TaskTrash interface {
+	RestoreTask(ctx context.Context, id uint64, uid uint64) (*models.Task, error)
+	PurgeTask(ctx context.Context, id uint64, uid uint64) error
+	PurgeTrash(ctx context.Context, before time.Time) ([]*models.Task, error)
 }
 
 func New(
@@ -45,96 +91,448 @@ func New(
 	getter TaskGetter,
 	creator TaskCreator,
 	updater TaskUpdater,
-	deleter TaskDeleter) *Task {
+	deleter TaskDeleter,
+	lister TaskLister,
+	trash TaskTrash,
+	history TaskHistory,
+	tags TaskTags,
+	tree TaskHierarchy,
+	dependencies TaskDependencies,
+	series TaskSeries,
+	tx Transactor,
+	workflow *models.Workflow) *Task {
 
 	return &Task{
-		logger:  log,
-		getter:  getter,
-		creator: creator,
-		updater: updater,
-		deleter: deleter,
+		logger:       log,
+		getter:       getter,
+		creator:      creator,
+		updater:      updater,
+		deleter:      deleter,
+		lister:       lister,
+		trash:        trash,
+		history:      history,
+		tags:         tags,
+		tree:         tree,
+		dependencies: dependencies,
+		series:       series,
+		tx:           tx,
+		workflow:     workflow,
+		events:       newBroker(),
 	}
 }
 
 func (t *Task) CreateTask(ctx context.Context, uid uint64, title, description string,
-	priority string) (*models.Task, error) {
+	priority models.Priority, dueDate *time.Time, tags []string) (_ *models.Task, err error) {
 	const op = "task.CreateTask"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
 	log := t.logger.With(
 		slog.String("op", op),
 	)
-	res, err := t.creator.CreateTask(ctx, uid, title, description, priority)
+	tags, err = normalizeTags(tags)
 	if err != nil {
-		log.Error("Failed to create task", sl.Err(err))
+		log.WarnContext(ctx, "invalid tags", sl.Err(err))
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	var res *models.Task
+	err = t.withinTx(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = t.create(ctx, uid, title, description, priority, dueDate, tags, nil)
+		return err
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to create task", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+// create stores a new task with its tags under an optional parent and
+// records it. It must run inside a transaction.
+func (t *Task) create(ctx context.Context, uid uint64, title, description string,
+	priority models.Priority, dueDate *time.Time, tags []string, parentId *uint64) (*models.Task, error) {
+	res, err := t.insert(ctx, uid, title, description, priority, dueDate, tags, parentId)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.record(ctx, res, models.ActionCreated, models.DiffTasks(nil, res)); err != nil {
+		return nil, err
+	}
 	return res, nil
 }
 
-func (t *Task) GetTask(ctx context.Context, id, uid uint64) (*models.Task, error) {
+// insert stores a task with its parent and tags, leaving recording the
+// creation to the caller.
+func (t *Task) insert(ctx context.Context, uid uint64, title, description string,
+	priority models.Priority, dueDate *time.Time, tags []string, parentId *uint64) (*models.Task, error) {
+	res, err := t.creator.CreateTask(ctx, uid, title, description, priority, dueDate)
+	if err != nil {
+		return nil, err
+	}
+	if parentId != nil {
+		if res, err = t.tree.MoveTask(ctx, res.Id, uid, 0, parentId); err != nil {
+			return nil, err
+		}
+	}
+	if len(tags) > 0 {
+		if err := t.tags.AddTaskTags(ctx, res.Id, uid, tags); err != nil {
+			return nil, err
+		}
+		if res, err = t.getter.GetTask(ctx, res.Id, uid); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (t *Task) GetTask(ctx context.Context, id, uid uint64) (_ *models.Task, err error) {
 	const op = "task.GetTask"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
 	log := t.logger.With(
 		slog.String("op", op),
 	)
 	res, err := t.getter.GetTask(ctx, id, uid)
 	if err != nil {
 		if errors.Is(err, storage.ErrTaskNotFound) {
-			log.Warn("task not found", sl.Err(err))
+			log.WarnContext(ctx, "task not found", sl.Err(err))
 			return nil, fmt.Errorf("%s: %w", op, ErrWrongId)
 		}
-		log.Error("failed to get task", sl.Err(err))
+		log.ErrorContext(ctx, "failed to get task", sl.Err(err))
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	return res, nil
 }
 
-func (t *Task) UpdateTask(ctx context.Context, id, uid uint64, title, description string,
-	priority string) (*models.Task, error) {
+// UpdateTask changes exactly the fields named in the update mask. A non-zero
+// version makes the write conditional on the task still being at that
+// version; the same applies to UpdateStatus and DeleteTask. Tags listed in
+// AddTags and RemoveTags are attached and detached in the same write, so an
+// update may carry an empty mask when it only changes tags.
+func (t *Task) UpdateTask(ctx context.Context, id, uid, version uint64,
+	update models.TaskUpdate) (_ *models.Task, err error) {
 	const op = "task.UpdateTask"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
 	log := t.logger.With(
 		slog.String("op", op),
 	)
-	res, err := t.updater.UpdateTask(ctx, id, uid, title, description, priority)
+	tagged := len(update.AddTags) > 0 || len(update.RemoveTags) > 0
+	mask, err := normalizeMask(update.Mask, tagged)
+	if err != nil {
+		log.WarnContext(ctx, "invalid update mask", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	update.Mask = mask
+	if update.AddTags, err = normalizeTags(update.AddTags); err != nil {
+		log.WarnContext(ctx, "invalid tags", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if update.RemoveTags, err = normalizeTags(update.RemoveTags); err != nil {
+		log.WarnContext(ctx, "invalid tags", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	var res *models.Task
+	err = t.withinTx(ctx, func(ctx context.Context) error {
+		before, err := t.getter.GetTask(ctx, id, uid)
+		if err != nil {
+			return err
+		}
+		res, err = t.updater.UpdateTask(ctx, id, uid, version, update)
+		if err != nil {
+			return err
+		}
+		if tagged {
+			if res, err = t.applyTags(ctx, id, uid, update); err != nil {
+				return err
+			}
+		}
+		return t.record(ctx, res, models.ActionUpdated, models.DiffTasks(before, res))
+	})
 	if err != nil {
 		if errors.Is(err, storage.ErrTaskNotFound) {
-			log.Warn("task not found", sl.Err(err))
+			log.WarnContext(ctx, "task not found", sl.Err(err))
 			return nil, fmt.Errorf("%s: %w", op, ErrWrongId)
 		}
-		log.Error("failed to update task", sl.Err(err))
+		if errors.Is(err, storage.ErrVersionMismatch) {
+			log.WarnContext(ctx, "version conflict", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, ErrVersionConflict)
+		}
+		log.ErrorContext(ctx, "failed to update task", sl.Err(err))
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	return res, nil
 }
 
-func (t *Task) UpdateStatus(ctx context.Context, id, uid uint64, status string) (*models.Task, error) {
-	const op = "task.UpdateTask"
+// UpdateStatus only applies transitions allowed by the workflow and returns
+// a TransitionError otherwise. It refuses to start or finish a task while
+// any of its blockers is still open and returns a BlockedError listing them. Finishing an
+// occurrence of a recurring task spawns the next one.
+func (t *Task) UpdateStatus(ctx context.Context, id, uid, version uint64, status models.Status) (_ *models.Task, err error) {
+	const op = "task.UpdateStatus"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
 	log := t.logger.With(
 		slog.String("op", op),
 	)
-	res, err := t.updater.UpdateStatus(ctx, id, uid, status)
+	var res *models.Task
+	err = t.withinTx(ctx, func(ctx context.Context) error {
+		before, err := t.getter.GetTask(ctx, id, uid)
+		if err != nil {
+			return err
+		}
+		if err := t.checkTransition(before.Status, status); err != nil {
+			return err
+		}
+		if status == models.StatusInProgress || status == models.StatusDone {
+			if err := t.checkBlockers(ctx, id, uid); err != nil {
+				return err
+			}
+		}
+		res, err = t.updater.UpdateStatus(ctx, id, uid, version, status)
+		if err != nil {
+			return err
+		}
+		if err := t.record(ctx, res, models.ActionStatusChanged, models.DiffTasks(before, res)); err != nil {
+			return err
+		}
+		if status == models.StatusDone && before.Status != models.StatusDone && res.SeriesId != nil {
+			return t.spawnNext(ctx, res)
+		}
+		return nil
+	})
 	if err != nil {
 		if errors.Is(err, storage.ErrTaskNotFound) {
-			log.Warn("task not found", sl.Err(err))
+			log.WarnContext(ctx, "task not found", sl.Err(err))
 			return nil, fmt.Errorf("%s: %w", op, ErrWrongId)
 		}
-		log.Error("failed to update status", sl.Err(err))
+		if errors.Is(err, storage.ErrVersionMismatch) {
+			log.WarnContext(ctx, "version conflict", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, ErrVersionConflict)
+		}
+		if errors.Is(err, ErrTaskBlocked) || errors.Is(err, ErrInvalidTransition) {
+			log.WarnContext(ctx, "status change rejected", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		log.ErrorContext(ctx, "failed to update status", sl.Err(err))
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	return res, nil
 }
 
-func (t *Task) DeleteTask(ctx context.Context, id, uid uint64) error {
-	const op = "task.UpdateTask"
+// DeleteTask moves a task to the trash, from where it can be restored until
+// it is purged. Its subtasks stay live and move up to the task's own parent.
+func (t *Task) DeleteTask(ctx context.Context, id, uid, version uint64) (err error) {
+	const op = "task.DeleteTask"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+	log := t.logger.With(
+		slog.String("op", op),
+	)
+	err = t.withinTx(ctx, func(ctx context.Context) error {
+		task, err := t.getter.GetTask(ctx, id, uid)
+		if err != nil {
+			return err
+		}
+		if err := t.reparentChildren(ctx, task); err != nil {
+			return err
+		}
+		if err := t.deleter.DeleteTask(ctx, id, uid, version); err != nil {
+			return err
+		}
+		return t.record(ctx, task, models.ActionDeleted, nil)
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrTaskNotFound) {
+			log.WarnContext(ctx, "task not found", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, ErrWrongId)
+		}
+		if errors.Is(err, storage.ErrVersionMismatch) {
+			log.WarnContext(ctx, "version conflict", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, ErrVersionConflict)
+		}
+		log.ErrorContext(ctx, "failed to delete task", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (t *Task) ListTasks(ctx context.Context, query models.ListTasksQuery) (_ *models.TaskPage, err error) {
+	const op = "task.ListTasks"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+	log := t.logger.With(
+		slog.String("op", op),
+	)
+	// Tags are matched as stored, and duplicates would make TagMatchAll
+	// ask for the same tag twice.
+	query.Filter.Tags, err = normalizeTags(query.Filter.Tags)
+	if err != nil {
+		log.Warn("invalid tag filter", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	query.PageSize = clampPageSize(query.PageSize)
+	if query.SortBy == "" {
+		query.SortBy = models.SortByCreatedAt
+	}
+	res, err := t.lister.ListTasks(ctx, query)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidCursor) {
+			log.WarnContext(ctx, "invalid page token", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, ErrInvalidPageToken)
+		}
+		log.ErrorContext(ctx, "failed to list tasks", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+// ListTrash pages through a user's deleted tasks, most recently deleted
+// first.
+func (t *Task) ListTrash(ctx context.Context, uid uint64, pageSize int, pageToken string) (_ *models.TaskPage, err error) {
+	const op = "task.ListTrash"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+	res, err := t.ListTasks(ctx, models.ListTasksQuery{
+		UserId:    uid,
+		Trashed:   true,
+		SortBy:    models.SortByDeletedAt,
+		Desc:      true,
+		PageSize:  pageSize,
+		PageToken: pageToken,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+func (t *Task) RestoreTask(ctx context.Context, id, uid uint64) (_ *models.Task, err error) {
+	const op = "task.RestoreTask"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+	log := t.logger.With(
+		slog.String("op", op),
+	)
+	var res *models.Task
+	err = t.withinTx(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = t.trash.RestoreTask(ctx, id, uid)
+		if err != nil {
+			return err
+		}
+		// A parent that was trashed or purged in the meantime leaves the
+		// restored task at the top level.
+		if res.ParentId != nil {
+			_, err := t.getter.GetTask(ctx, *res.ParentId, uid)
+			if errors.Is(err, storage.ErrTaskNotFound) {
+				res, err = t.tree.MoveTask(ctx, id, uid, 0, nil)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return t.record(ctx, res, models.ActionRestored, nil)
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrTaskNotFound) {
+			log.WarnContext(ctx, "task not found in trash", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, ErrWrongId)
+		}
+		log.ErrorContext(ctx, "failed to restore task", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+// PurgeTask permanently removes a task that is already in the trash.
+func (t *Task) PurgeTask(ctx context.Context, id, uid uint64) (err error) {
+	const op = "task.PurgeTask"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
 	log := t.logger.With(
 		slog.String("op", op),
 	)
-	err := t.deleter.DeleteTask(ctx, id, uid)
+	err = t.withinTx(ctx, func(ctx context.Context) error {
+		if err := t.trash.PurgeTask(ctx, id, uid); err != nil {
+			return err
+		}
+		return t.record(ctx, &models.Task{Id: id, UserId: uid}, models.ActionPurged, nil)
+	})
 	if err != nil {
 		if errors.Is(err, storage.ErrTaskNotFound) {
-			log.Warn("task not found", sl.Err(err))
+			log.WarnContext(ctx, "task not found in trash", sl.Err(err))
 			return fmt.Errorf("%s: %w", op, ErrWrongId)
 		}
-		log.Error("failed to delete task", sl.Err(err))
+		log.ErrorContext(ctx, "failed to purge task", sl.Err(err))
 		return fmt.Errorf("%s: %w", op, err)
 	}
 	return nil
 }
+
+// PurgeTrash permanently removes every task that has been in the trash for
+// longer than retention, recording each removal as PurgeTask does.
+func (t *Task) PurgeTrash(ctx context.Context, retention time.Duration) (_ int64, err error) {
+	const op = "task.PurgeTrash"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+	log := t.logger.With(
+		slog.String("op", op),
+	)
+	var purged []*models.Task
+	err = t.withinTx(ctx, func(ctx context.Context) error {
+		purged, err = t.trash.PurgeTrash(ctx, time.Now().Add(-retention))
+		if err != nil {
+			return err
+		}
+		slices.SortFunc(purged, func(a, b *models.Task) int { return cmp.Compare(a.Id, b.Id) })
+		for _, task := range purged {
+			if err := t.record(ctx, task, models.ActionPurged, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to purge trash", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return int64(len(purged)), nil
+}
+
+func clampPageSize(pageSize int) int {
+	if pageSize <= 0 {
+		return DefaultPageSize
+	}
+	return min(pageSize, MaxPageSize)
+}
+
+// normalizeMask rejects unknown masks and drops duplicate paths. An empty
+// mask is only accepted when allowEmpty is set.
+func normalizeMask(mask []string, allowEmpty bool) ([]string, error) {
+	if len(mask) == 0 && !allowEmpty {
+		return nil, fmt.Errorf("%w: no fields to update", ErrInvalidMask)
+	}
+	res := make([]string, 0, len(mask))
+	seen := make(map[string]bool, len(mask))
+	for _, field := range mask {
+		if !slices.Contains(models.UpdatableFields, field) {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidMask, field)
+		}
+		if !seen[field] {
+			seen[field] = true
+			res = append(res, field)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,159 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage/storagetest"
+	"testing"
+	"time"
+)
+
+func TestDueDateRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	due := time.Date(2031, time.March, 9, 17, 30, 0, 0, time.FixedZone("CET", 3600))
+	for _, backend := range testServices(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			s, uid := backend.service, storagetest.NewUserId()
+			created, err := s.CreateTask(ctx, uid, "title", "description", models.PriorityLow, &due, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.GetTask(ctx, created.Id, uid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.DueDate == nil || !got.DueDate.Equal(due) {
+				t.Fatalf("due date = %v, want %v", got.DueDate, due)
+			}
+
+			later := due.Add(48 * time.Hour)
+			moved, err := s.UpdateTask(ctx, got.Id, uid, 0, models.TaskUpdate{
+				Mask:    []string{models.FieldDueDate},
+				DueDate: &later,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if moved.DueDate == nil || !moved.DueDate.Equal(later) {
+				t.Fatalf("moved due date = %v, want %v", moved.DueDate, later)
+			}
+
+			cleared, err := s.UpdateTask(ctx, got.Id, uid, 0, models.TaskUpdate{Mask: []string{models.FieldDueDate}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, err := s.GetTask(ctx, got.Id, uid); err != nil || cleared.DueDate != nil || got.DueDate != nil {
+				t.Fatalf("cleared due date = %v, stored %v, %v", cleared.DueDate, got.DueDate, err)
+			}
+		})
+	}
+}
+
+func TestUpdateMask(t *testing.T) {
+	ctx := context.Background()
+	for _, backend := range testServices(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			s, uid := backend.service, storagetest.NewUserId()
+			created, err := s.CreateTask(ctx, uid, "title", "description", models.PriorityHigh, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Fields outside the mask keep their values even when the
+			// update carries them.
+			updated, err := s.UpdateTask(ctx, created.Id, uid, 0, models.TaskUpdate{
+				Mask:        []string{models.FieldTitle, models.FieldDescription},
+				Title:       "renamed",
+				Priority:    models.PriorityLow,
+				Description: "",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if updated.Title != "renamed" || updated.Description != "" || updated.Priority != models.PriorityHigh {
+				t.Fatalf("updated = %+v", updated)
+			}
+
+			for name, mask := range map[string][]string{
+				"unknown path": {models.FieldTitle, "owner"},
+				"status":       {models.FieldStatus},
+				"empty":        nil,
+			} {
+				_, err := s.UpdateTask(ctx, created.Id, uid, 0, models.TaskUpdate{Mask: mask, Title: "x"})
+				if !errors.Is(err, ErrInvalidMask) {
+					t.Errorf("%s: err = %v, want %v", name, err, ErrInvalidMask)
+				}
+			}
+			got, err := s.GetTask(ctx, created.Id, uid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Title != "renamed" || got.Version != updated.Version {
+				t.Fatalf("rejected masks changed the task: %+v", got)
+			}
+		})
+	}
+}
+
+func TestStaleVersionWritesNothing(t *testing.T) {
+	ctx := context.Background()
+	for _, backend := range testServices(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			s, uid := backend.service, storagetest.NewUserId()
+			created, err := s.CreateTask(ctx, uid, "title", "description", models.PriorityLow, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			current, err := s.UpdateTask(ctx, created.Id, uid, created.Version, models.TaskUpdate{
+				Mask:  []string{models.FieldTitle},
+				Title: "second",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			before, err := s.GetTaskHistory(ctx, created.Id, uid, 0, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stale := created.Version
+			writes := map[string]func() error{
+				"update": func() error {
+					_, err := s.UpdateTask(ctx, created.Id, uid, stale, models.TaskUpdate{
+						Mask:  []string{models.FieldTitle},
+						Title: "lost",
+					})
+					return err
+				},
+				"update status": func() error {
+					_, err := s.UpdateStatus(ctx, created.Id, uid, stale, models.StatusInProgress)
+					return err
+				},
+				"delete": func() error {
+					return s.DeleteTask(ctx, created.Id, uid, stale)
+				},
+			}
+			for name, write := range writes {
+				if err := write(); !errors.Is(err, ErrVersionConflict) {
+					t.Errorf("%s: err = %v, want %v", name, err, ErrVersionConflict)
+				}
+			}
+
+			got, err := s.GetTask(ctx, created.Id, uid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Title != "second" || got.Status != models.StatusTodo || got.Version != current.Version {
+				t.Fatalf("stale writes changed the task: %+v", got)
+			}
+			after, err := s.GetTaskHistory(ctx, created.Id, uid, 0, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(after.Entries) != len(before.Entries) {
+				t.Fatalf("stale writes recorded %d history entries", len(after.Entries)-len(before.Entries))
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package task
+
+import (
+	"context"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage/storagetest"
+	"testing"
+)
+
+func TestPurgeTrashIsRecordedAndNotified(t *testing.T) {
+	ctx := context.Background()
+	for _, backend := range testServices(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			s := backend.service
+			users := []uint64{storagetest.NewUserId(), storagetest.NewUserId()}
+			trashed := make(map[uint64]uint64)
+			var wakes []<-chan struct{}
+			for _, uid := range users {
+				for _, title := range []string{"trashed", "kept"} {
+					task, err := s.CreateTask(ctx, uid, title, "", models.PriorityLow, nil, nil)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if title == "trashed" {
+						if err := s.DeleteTask(ctx, task.Id, uid, 0); err != nil {
+							t.Fatal(err)
+						}
+						trashed[uid] = task.Id
+					}
+				}
+				wake, unsubscribe := s.events.subscribe(uid)
+				defer unsubscribe()
+				wakes = append(wakes, wake)
+			}
+
+			purged, err := s.PurgeTrash(ctx, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The trash may hold tasks of other tests sharing a database.
+			if purged < int64(len(users)) {
+				t.Errorf("purged %d tasks, want at least %d", purged, len(users))
+			}
+			for i, uid := range users {
+				expectWake(t, wakes[i], "PurgeTrash")
+				last, err := s.history.LastHistoryId(ctx, uid)
+				if err != nil {
+					t.Fatal(err)
+				}
+				entries, err := s.history.ListUserHistory(ctx, uid, last-1, 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(entries) != 1 || entries[0].Action != models.ActionPurged || entries[0].TaskId != trashed[uid] {
+					t.Errorf("last history entry of user %d = %+v, want purge of task %d", uid, entries, trashed[uid])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/lib/metrics"
+	"github.com/Citadelas/task/internal/lib/tracing"
+	"strings"
+	"time"
+)
+
+var ErrInvalidTransition = errors.New("status transition not allowed")
+
+// TransitionError reports a status change the workflow does not allow.
+type TransitionError struct {
+	From    models.Status
+	To      models.Status
+	Allowed []models.Status
+}
+
+func (e *TransitionError) Error() string {
+	names := make([]string, len(e.Allowed))
+	for i, status := range e.Allowed {
+		names[i] = status.String()
+	}
+	allowed := strings.Join(names, ", ")
+	if allowed == "" {
+		allowed = "none"
+	}
+	return fmt.Sprintf("%s: %s -> %s, allowed: %s", ErrInvalidTransition, e.From, e.To, allowed)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
+
+// NextStatuses lists the statuses a task in the given status may move to.
+func (t *Task) NextStatuses(status models.Status) []models.Status {
+	return t.workflow.Next(status)
+}
+
+// AllowedStatuses lists the statuses a task may move to next.
+func (t *Task) AllowedStatuses(ctx context.Context, id, uid uint64) (_ []models.Status, err error) {
+	const op = "task.AllowedStatuses"
+	defer metrics.TrackOperation(op, time.Now(), &err)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+	task, err := t.GetTask(ctx, id, uid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return t.NextStatuses(task.Status), nil
+}
+
+func (t *Task) checkTransition(from, to models.Status) error {
+	if !t.workflow.Allowed(from, to) {
+		return &TransitionError{From: from, To: to, Allowed: t.workflow.Next(from)}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage/storagetest"
+	"slices"
+	"testing"
+)
+
+func TestWorkflowRejectsDisallowedTransition(t *testing.T) {
+	// Work must start before it can finish, and finished work stays done.
+	workflow, err := models.NewWorkflow(map[string][]string{
+		"todo":        {"in_progress"},
+		"in_progress": {"todo", "done"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, backend := range testServicesWithWorkflow(t, workflow) {
+		t.Run(backend.name, func(t *testing.T) {
+			s, uid := backend.service, storagetest.NewUserId()
+			task, err := s.CreateTask(ctx, uid, "title", "", models.PriorityLow, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = s.UpdateStatus(ctx, task.Id, uid, 0, models.StatusDone)
+			var transition *TransitionError
+			if !errors.As(err, &transition) {
+				t.Fatalf("TODO -> DONE: err = %v, want a TransitionError", err)
+			}
+			if transition.From != models.StatusTodo || transition.To != models.StatusDone ||
+				!slices.Equal(transition.Allowed, []models.Status{models.StatusInProgress}) {
+				t.Fatalf("transition error = %+v", transition)
+			}
+			if got, err := s.GetTask(ctx, task.Id, uid); err != nil || got.Status != models.StatusTodo {
+				t.Fatalf("rejected transition changed the task: %+v, %v", got, err)
+			}
+
+			for _, status := range []models.Status{models.StatusInProgress, models.StatusDone} {
+				if _, err := s.UpdateStatus(ctx, task.Id, uid, 0, status); err != nil {
+					t.Fatalf("-> %s: %v", status, err)
+				}
+			}
+			allowed, err := s.AllowedStatuses(ctx, task.Id, uid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(allowed) != 0 {
+				t.Fatalf("allowed after DONE = %v, want none", allowed)
+			}
+			if _, err := s.UpdateStatus(ctx, task.Id, uid, 0, models.StatusTodo); !errors.Is(err, ErrInvalidTransition) {
+				t.Fatalf("DONE -> TODO: err = %v, want %v", err, ErrInvalidTransition)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"strings"
+	"time"
+)
+
+// NoDueDate stands in for a missing due date when ordering by due date,
+// so that tasks without one sort after every dated task.
+var NoDueDate = time.Date(9999, time.December, 31, 23, 59, 59, 0, time.UTC)
+
+// Cursor is the keyset position of the last task returned on a page.
+// It is handed to clients as an opaque token and is only valid for the
+// sort order it was produced with.
+type Cursor struct {
+	SortBy models.TaskSortField `json:"s"`
+	Desc   bool                 `json:"d,omitempty"`
+	Time   time.Time            `json:"t,omitempty"`
+	Rank   int                  `json:"r,omitempty"`
+	ID     uint64               `json:"i"`
+}
+
+// PriorityRankExpr returns an SQL expression that ranks column the same way
+// as models.Priority.Rank, so keyset pages agree with in-memory ordering.
+func PriorityRankExpr(column string) string {
+	var b strings.Builder
+	b.WriteString("CASE " + column)
+	for _, priority := range models.Priorities {
+		fmt.Fprintf(&b, " WHEN '%s' THEN %d", priority, priority.Rank())
+	}
+	b.WriteString(" ELSE -1 END")
+	return b.String()
+}
+
+// CursorAfter builds the cursor pointing right after the given task.
+func CursorAfter(task *models.Task, sortBy models.TaskSortField, desc bool) Cursor {
+	c := Cursor{SortBy: sortBy, Desc: desc, ID: task.Id}
+	switch sortBy {
+	case models.SortByDueDate:
+		c.Time = NoDueDate
+		if task.DueDate != nil {
+			c.Time = *task.DueDate
+		}
+	case models.SortByPriority:
+		c.Rank = task.Priority.Rank()
+	case models.SortByDeletedAt:
+		if task.DeletedAt != nil {
+			c.Time = *task.DeletedAt
+		}
+	default:
+		c.Time = task.CreatedAt
+	}
+	return c
+}
+
+func EncodeCursor(c Cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a page token and checks that it was issued for the
+// same sort order as the current query.
+func DecodeCursor(token string, sortBy models.TaskSortField, desc bool) (*Cursor, error) {
+	const op = "storage.DecodeCursor"
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCursor)
+	}
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCursor)
+	}
+	if c.SortBy != sortBy || c.Desc != desc || c.ID == 0 {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCursor)
+	}
+	return &c, nil
+}
+
+// HistorySort identifies cursors over a task's change history, which is
+// paged in insertion order.
+const HistorySort models.TaskSortField = "history"
+
+// HistoryPageOf trims entries fetched with one extra row to pageSize and
+// sets the next page token when more entries exist.
+func HistoryPageOf(entries []*models.HistoryEntry, pageSize int) *models.HistoryPage {
+	page := &models.HistoryPage{Entries: entries}
+	if len(entries) > pageSize {
+		page.Entries = entries[:pageSize]
+		last := page.Entries[len(page.Entries)-1]
+		page.NextPageToken = EncodeCursor(Cursor{SortBy: HistorySort, ID: last.Id})
+	}
+	return page
+}
@@ -0,0 +1,43 @@
+package storage_test
+
+import (
+	"errors"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	task := &models.Task{Id: 7, CreatedAt: time.Date(2031, time.March, 2, 8, 30, 0, 123456789, time.UTC)}
+	token := storage.EncodeCursor(storage.CursorAfter(task, models.SortByCreatedAt, true))
+	c, err := storage.DecodeCursor(token, models.SortByCreatedAt, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ID != task.Id || !c.Time.Equal(task.CreatedAt) {
+		t.Errorf("cursor = %+v, want id %d at %v", c, task.Id, task.CreatedAt)
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	task := &models.Task{Id: 7, CreatedAt: time.Now()}
+	token := storage.EncodeCursor(storage.CursorAfter(task, models.SortByCreatedAt, false))
+	tokens := map[string]struct {
+		token  string
+		sortBy models.TaskSortField
+		desc   bool
+	}{
+		"garbage":         {token: "not a token", sortBy: models.SortByCreatedAt},
+		"not json":        {token: "bm90IGpzb24", sortBy: models.SortByCreatedAt},
+		"other sort":      {token: token, sortBy: models.SortByPriority},
+		"other direction": {token: token, sortBy: models.SortByCreatedAt, desc: true},
+		"no id": {token: storage.EncodeCursor(storage.Cursor{SortBy: models.SortByCreatedAt}),
+			sortBy: models.SortByCreatedAt},
+	}
+	for name, tt := range tokens {
+		if _, err := storage.DecodeCursor(tt.token, tt.sortBy, tt.desc); !errors.Is(err, storage.ErrInvalidCursor) {
+			t.Errorf("%s: error = %v, want %v", name, err, storage.ErrInvalidCursor)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage"
+	"slices"
+)
+
+type dependency struct {
+	taskId    uint64
+	blockerId uint64
+}
+
+// AddDependency records that blockerId blocks id, see the postgres storage.
+func (s *Storage) AddDependency(ctx context.Context, id uint64, blockerId uint64) error {
+	defer s.lock(ctx)()
+	s.dependencies[dependency{taskId: id, blockerId: blockerId}] = true
+	return nil
+}
+
+func (s *Storage) RemoveDependency(ctx context.Context, id uint64, blockerId uint64, uid uint64) error {
+	const op = "storage.memory.RemoveDependency"
+	defer s.lock(ctx)()
+	dep := dependency{taskId: id, blockerId: blockerId}
+	if task, ok := s.tasks[id]; !ok || task.UserId != uid || !s.dependencies[dep] {
+		return fmt.Errorf("%s: %w", op, storage.ErrDependencyNotFound)
+	}
+	delete(s.dependencies, dep)
+	return nil
+}
+
+// ListBlockers returns the live tasks that block id.
+func (s *Storage) ListBlockers(ctx context.Context, id uint64, uid uint64) ([]*models.Task, error) {
+	defer s.rlock(ctx)()
+	var tasks []*models.Task
+	for dep := range s.dependencies {
+		if dep.taskId != id {
+			continue
+		}
+		if blocker, ok := s.find(dep.blockerId, uid); ok {
+			tasks = append(tasks, copyTask(blocker))
+		}
+	}
+	slices.SortFunc(tasks, func(a, b *models.Task) int { return cmp.Compare(a.Id, b.Id) })
+	return tasks, nil
+}
+
+// ListTransitiveBlockers returns the ids of every task that blocks id
+// directly or through other tasks, trashed ones included.
+func (s *Storage) ListTransitiveBlockers(ctx context.Context, id uint64) ([]uint64, error) {
+	defer s.rlock(ctx)()
+	seen := make(map[uint64]bool)
+	var ids []uint64
+	for queue := []uint64{id}; len(queue) > 0; queue = queue[1:] {
+		for dep := range s.dependencies {
+			if dep.taskId == queue[0] && !seen[dep.blockerId] {
+				seen[dep.blockerId] = true
+				ids = append(ids, dep.blockerId)
+				queue = append(queue, dep.blockerId)
+			}
+		}
+	}
+	return ids, nil
+}
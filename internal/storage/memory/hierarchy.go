@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage"
+	"slices"
+)
+
+// MoveTask sets the parent of a task, see the postgres storage.
+func (s *Storage) MoveTask(ctx context.Context, id uint64, uid uint64, version uint64,
+	parentId *uint64) (*models.Task, error) {
+	const op = "storage.memory.MoveTask"
+	defer s.lock(ctx)()
+	task, err := s.findVersion(id, uid, version)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	task.ParentId = copyID(parentId)
+	task.Version++
+	return copyTask(task), nil
+}
+
+// ListSubtree returns a task followed by all of its live descendants in
+// creation order.
+func (s *Storage) ListSubtree(ctx context.Context, id uint64, uid uint64) ([]*models.Task, error) {
+	const op = "storage.memory.ListSubtree"
+	defer s.rlock(ctx)()
+	root, ok := s.find(id, uid)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrTaskNotFound)
+	}
+	seen := map[uint64]bool{id: true}
+	var descendants []*models.Task
+	for queue := []uint64{id}; len(queue) > 0; queue = queue[1:] {
+		for _, task := range s.tasks {
+			if task.DeletedAt == nil && task.ParentId != nil && *task.ParentId == queue[0] && !seen[task.Id] {
+				seen[task.Id] = true
+				descendants = append(descendants, copyTask(task))
+				queue = append(queue, task.Id)
+			}
+		}
+	}
+	slices.SortFunc(descendants, func(a, b *models.Task) int {
+		if res := a.CreatedAt.Compare(b.CreatedAt); res != 0 {
+			return res
+		}
+		return cmp.Compare(a.Id, b.Id)
+	})
+	return append([]*models.Task{copyTask(root)}, descendants...), nil
+}
@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage"
+	"time"
+)
+
+func (s *Storage) AddHistory(ctx context.Context, entry models.HistoryEntry) (*models.HistoryEntry, error) {
+	defer s.lock(ctx)()
+	s.lastHistoryID++
+	entry.Id = s.lastHistoryID
+	entry.Changes = append(models.FieldChanges{}, entry.Changes...)
+	entry.CreatedAt = time.Now()
+	s.history = append(s.history, &entry)
+	res := entry
+	return &res, nil
+}
+
+func (s *Storage) ListHistory(ctx context.Context, id uint64, uid uint64,
+	pageSize int, pageToken string) (*models.HistoryPage, error) {
+	const op = "storage.memory.ListHistory"
+	var after uint64
+	if pageToken != "" {
+		cursor, err := storage.DecodeCursor(pageToken, storage.HistorySort, false)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		after = cursor.ID
+	}
+	defer s.rlock(ctx)()
+	var entries []*models.HistoryEntry
+	for _, entry := range s.history {
+		if entry.TaskId != id || entry.UserId != uid || entry.Id <= after {
+			continue
+		}
+		res := *entry
+		entries = append(entries, &res)
+		if len(entries) > pageSize {
+			break
+		}
+	}
+	return storage.HistoryPageOf(entries, pageSize), nil
+}
+
+func (s *Storage) ListUserHistory(ctx context.Context, uid uint64, after uint64, limit int) ([]*models.HistoryEntry, error) {
+	defer s.rlock(ctx)()
+	var entries []*models.HistoryEntry
+	for _, entry := range s.history {
+		if entry.UserId != uid || entry.Id <= after {
+			continue
+		}
+		res := *entry
+		entries = append(entries, &res)
+		if len(entries) == limit {
+			break
+		}
+	}
+	return entries, nil
+}
+
+func (s *Storage) LastHistoryId(ctx context.Context, uid uint64) (uint64, error) {
+	defer s.rlock(ctx)()
+	for i := len(s.history) - 1; i >= 0; i-- {
+		if s.history[i].UserId == uid {
+			return s.history[i].Id, nil
+		}
+	}
+	return 0, nil
+}
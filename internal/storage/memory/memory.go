@@ -0,0 +1,334 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage"
+	"slices"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Column limits mirrored from the postgres schema so that both backends
+// reject the same input with storage.ErrInputTooLong.
+const (
+	maxTitleLen    = 255
+	maxPriorityLen = 50
+	maxStatusLen   = 50
+	maxTagLen      = 50
+	maxRuleLen     = 255
+)
+
+type Storage struct {
+	mu            sync.RWMutex
+	tasks         map[uint64]*models.Task
+	lastID        uint64
+	history       []*models.HistoryEntry
+	lastHistoryID uint64
+	tags          map[uint64]*models.Tag
+	lastTagID     uint64
+	dependencies  map[dependency]bool
+	series        map[uint64]*models.Series
+	lastSeriesID  uint64
+}
+
+func New() *Storage {
+	return &Storage{
+		tasks:        make(map[uint64]*models.Task),
+		tags:         make(map[uint64]*models.Tag),
+		dependencies: make(map[dependency]bool),
+		series:       make(map[uint64]*models.Series),
+	}
+}
+
+func (s *Storage) CreateTask(ctx context.Context, uid uint64, title, description string,
+	priority models.Priority, dueDate *time.Time) (*models.Task, error) {
+	const op = "storage.memory.CreateTask"
+	if tooLong(title, maxTitleLen) || tooLong(string(priority), maxPriorityLen) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInputTooLong)
+	}
+	defer s.lock(ctx)()
+	s.lastID++
+	task := &models.Task{
+		Id:          s.lastID,
+		UserId:      uid,
+		Title:       title,
+		Description: description,
+		Priority:    priority,
+		Status:      models.StatusTodo,
+		CreatedAt:   time.Now(),
+		DueDate:     copyTime(dueDate),
+		Version:     1,
+	}
+	s.tasks[task.Id] = task
+	return copyTask(task), nil
+}
+
+func (s *Storage) GetTask(ctx context.Context, id uint64, uid uint64) (*models.Task, error) {
+	const op = "storage.memory.GetTask"
+	defer s.rlock(ctx)()
+	task, ok := s.find(id, uid)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrTaskNotFound)
+	}
+	return copyTask(task), nil
+}
+
+func (s *Storage) UpdateTask(ctx context.Context, id uint64, uid uint64, version uint64,
+	update models.TaskUpdate) (*models.Task, error) {
+	const op = "storage.memory.UpdateTask"
+	if (update.Has(models.FieldTitle) && tooLong(update.Title, maxTitleLen)) ||
+		(update.Has(models.FieldPriority) && tooLong(string(update.Priority), maxPriorityLen)) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInputTooLong)
+	}
+	defer s.lock(ctx)()
+	task, err := s.findVersion(id, uid, version)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	for _, field := range update.Mask {
+		switch field {
+		case models.FieldTitle:
+			task.Title = update.Title
+		case models.FieldDescription:
+			task.Description = update.Description
+		case models.FieldPriority:
+			task.Priority = update.Priority
+		case models.FieldDueDate:
+			task.DueDate = copyTime(update.DueDate)
+		}
+	}
+	task.Version++
+	return copyTask(task), nil
+}
+
+func (s *Storage) UpdateStatus(ctx context.Context, id uint64, uid uint64, version uint64,
+	status models.Status) (*models.Task, error) {
+	const op = "storage.memory.UpdateStatus"
+	if tooLong(string(status), maxStatusLen) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInputTooLong)
+	}
+	defer s.lock(ctx)()
+	task, err := s.findVersion(id, uid, version)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	task.Status = status
+	task.Version++
+	return copyTask(task), nil
+}
+
+// DeleteTask moves a task to the trash, see the postgres storage.
+func (s *Storage) DeleteTask(ctx context.Context, id uint64, uid uint64, version uint64) error {
+	const op = "storage.memory.DeleteTask"
+	defer s.lock(ctx)()
+	task, err := s.findVersion(id, uid, version)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	now := time.Now()
+	task.DeletedAt = &now
+	task.Version++
+	return nil
+}
+
+func (s *Storage) RestoreTask(ctx context.Context, id uint64, uid uint64) (*models.Task, error) {
+	const op = "storage.memory.RestoreTask"
+	defer s.lock(ctx)()
+	task, ok := s.findTrashed(id, uid)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrTaskNotFound)
+	}
+	task.DeletedAt = nil
+	task.Version++
+	return copyTask(task), nil
+}
+
+func (s *Storage) PurgeTask(ctx context.Context, id uint64, uid uint64) error {
+	const op = "storage.memory.PurgeTask"
+	defer s.lock(ctx)()
+	if _, ok := s.findTrashed(id, uid); !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrTaskNotFound)
+	}
+	s.remove(id)
+	return nil
+}
+
+func (s *Storage) PurgeTrash(ctx context.Context, before time.Time) ([]*models.Task, error) {
+	defer s.lock(ctx)()
+	var purged []*models.Task
+	for id, task := range s.tasks {
+		if task.DeletedAt != nil && task.DeletedAt.Before(before) {
+			purged = append(purged, &models.Task{Id: id, UserId: task.UserId})
+			s.remove(id)
+		}
+	}
+	return purged, nil
+}
+
+func (s *Storage) ListTasks(ctx context.Context, query models.ListTasksQuery) (*models.TaskPage, error) {
+	const op = "storage.memory.ListTasks"
+	var after *storage.Cursor
+	if query.PageToken != "" {
+		cursor, err := storage.DecodeCursor(query.PageToken, query.SortBy, query.Desc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		after = cursor
+	}
+
+	unlock := s.rlock(ctx)
+	var tasks []*models.Task
+	for _, task := range s.tasks {
+		if task.UserId != query.UserId || (task.DeletedAt != nil) != query.Trashed || !matches(task, query.Filter) {
+			continue
+		}
+		if after != nil && compare(storage.CursorAfter(task, query.SortBy, query.Desc), *after, query.Desc) <= 0 {
+			continue
+		}
+		tasks = append(tasks, copyTask(task))
+	}
+	unlock()
+
+	slices.SortFunc(tasks, func(a, b *models.Task) int {
+		return compare(storage.CursorAfter(a, query.SortBy, query.Desc),
+			storage.CursorAfter(b, query.SortBy, query.Desc), query.Desc)
+	})
+	page := &models.TaskPage{Tasks: tasks}
+	if len(tasks) > query.PageSize {
+		page.Tasks = tasks[:query.PageSize]
+		last := page.Tasks[len(page.Tasks)-1]
+		page.NextPageToken = storage.EncodeCursor(storage.CursorAfter(last, query.SortBy, query.Desc))
+	}
+	return page, nil
+}
+
+// remove deletes a task with its dependencies and detaches its children,
+// like the ON DELETE clauses of the SQL schemas.
+func (s *Storage) remove(id uint64) {
+	delete(s.tasks, id)
+	for dep := range s.dependencies {
+		if dep.taskId == id || dep.blockerId == id {
+			delete(s.dependencies, dep)
+		}
+	}
+	for _, task := range s.tasks {
+		if task.ParentId != nil && *task.ParentId == id {
+			task.ParentId = nil
+		}
+	}
+}
+
+func (s *Storage) find(id, uid uint64) (*models.Task, bool) {
+	task, ok := s.tasks[id]
+	if !ok || task.UserId != uid || task.DeletedAt != nil {
+		return nil, false
+	}
+	return task, true
+}
+
+func (s *Storage) findTrashed(id, uid uint64) (*models.Task, bool) {
+	task, ok := s.tasks[id]
+	if !ok || task.UserId != uid || task.DeletedAt == nil {
+		return nil, false
+	}
+	return task, true
+}
+
+// findVersion looks a task up for a conditional write. A zero version
+// matches any current version.
+func (s *Storage) findVersion(id, uid, version uint64) (*models.Task, error) {
+	task, ok := s.find(id, uid)
+	if !ok {
+		return nil, storage.ErrTaskNotFound
+	}
+	if version != 0 && task.Version != version {
+		return nil, storage.ErrVersionMismatch
+	}
+	return task, nil
+}
+
+func matches(task *models.Task, filter models.TaskFilter) bool {
+	if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, task.Status) {
+		return false
+	}
+	if len(filter.Priorities) > 0 && !slices.Contains(filter.Priorities, task.Priority) {
+		return false
+	}
+	if filter.DueFrom != nil && (task.DueDate == nil || task.DueDate.Before(*filter.DueFrom)) {
+		return false
+	}
+	if filter.DueTo != nil && (task.DueDate == nil || !task.DueDate.Before(*filter.DueTo)) {
+		return false
+	}
+	if len(filter.Tags) > 0 && !matchesTags(task.Tags, filter.Tags, filter.TagMatch) {
+		return false
+	}
+	return true
+}
+
+func matchesTags(tags, want []string, match models.TagMatch) bool {
+	for _, tag := range want {
+		found := slices.Contains(tags, tag)
+		if found && match != models.TagMatchAll {
+			return true
+		}
+		if !found && match == models.TagMatchAll {
+			return false
+		}
+	}
+	return match == models.TagMatchAll
+}
+
+// compare orders two keyset positions the same way the SQL backends do:
+// by sort key, then by id, both in the requested direction.
+func compare(a, b storage.Cursor, desc bool) int {
+	res := a.Time.Compare(b.Time)
+	if res == 0 {
+		res = a.Rank - b.Rank
+	}
+	if res == 0 {
+		switch {
+		case a.ID < b.ID:
+			res = -1
+		case a.ID > b.ID:
+			res = 1
+		}
+	}
+	if desc {
+		return -res
+	}
+	return res
+}
+
+func tooLong(value string, limit int) bool {
+	return utf8.RuneCountInString(value) > limit
+}
+
+func copyTask(task *models.Task) *models.Task {
+	res := *task
+	res.DueDate = copyTime(task.DueDate)
+	res.DeletedAt = copyTime(task.DeletedAt)
+	res.Tags = slices.Clone(task.Tags)
+	res.ParentId = copyID(task.ParentId)
+	res.SeriesId = copyID(task.SeriesId)
+	return &res
+}
+
+func copyID(id *uint64) *uint64 {
+	if id == nil {
+		return nil
+	}
+	res := *id
+	return &res
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	res := *t
+	return &res
+}
@@ -0,0 +1,8 @@
+package memory
+
+import "context"
+
+// Ping always succeeds; the data lives in this process.
+func (s *Storage) Ping(ctx context.Context) error {
+	return nil
+}
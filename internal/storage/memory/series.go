@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage"
+	"time"
+)
+
+func (s *Storage) CreateSeries(ctx context.Context, uid uint64, rule string, startsAt time.Time) (*models.Series, error) {
+	const op = "storage.memory.CreateSeries"
+	if tooLong(rule, maxRuleLen) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInputTooLong)
+	}
+	defer s.lock(ctx)()
+	s.lastSeriesID++
+	series := &models.Series{Id: s.lastSeriesID, UserId: uid, Rule: rule, StartsAt: startsAt, CreatedAt: time.Now()}
+	s.series[series.Id] = series
+	copied := *series
+	return &copied, nil
+}
+
+func (s *Storage) GetSeries(ctx context.Context, id uint64, uid uint64) (*models.Series, error) {
+	const op = "storage.memory.GetSeries"
+	defer s.rlock(ctx)()
+	series, ok := s.series[id]
+	if !ok || series.UserId != uid {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrSeriesNotFound)
+	}
+	copied := *series
+	return &copied, nil
+}
+
+func (s *Storage) UpdateSeries(ctx context.Context, id uint64, uid uint64, rule string,
+	stopped bool) (*models.Series, error) {
+	const op = "storage.memory.UpdateSeries"
+	if tooLong(rule, maxRuleLen) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInputTooLong)
+	}
+	defer s.lock(ctx)()
+	series, ok := s.series[id]
+	if !ok || series.UserId != uid {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrSeriesNotFound)
+	}
+	series.Rule, series.Stopped = rule, stopped
+	copied := *series
+	return &copied, nil
+}
+
+// SetOccurrence makes a task the given occurrence of a series.
+func (s *Storage) SetOccurrence(ctx context.Context, id uint64, uid uint64, seriesId uint64,
+	occurrence int) (*models.Task, error) {
+	const op = "storage.memory.SetOccurrence"
+	defer s.lock(ctx)()
+	task, ok := s.find(id, uid)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrTaskNotFound)
+	}
+	task.SeriesId, task.Occurrence = &seriesId, occurrence
+	task.Version++
+	return copyTask(task), nil
+}
+
+// OccurrenceExists reports whether a series already has the given
+// occurrence, trashed or not.
+func (s *Storage) OccurrenceExists(ctx context.Context, seriesId uint64, occurrence int) (bool, error) {
+	defer s.rlock(ctx)()
+	for _, task := range s.tasks {
+		if task.SeriesId != nil && *task.SeriesId == seriesId && task.Occurrence == occurrence {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,18 @@
+package memory
+
+import (
+	"context"
+	"github.com/Citadelas/task/internal/domain/models"
+)
+
+// CountTasksByStatus counts the tasks outside the trash, by status.
+func (s *Storage) CountTasksByStatus(ctx context.Context) (map[models.Status]int64, error) {
+	defer s.rlock(ctx)()
+	res := make(map[models.Status]int64)
+	for _, task := range s.tasks {
+		if task.DeletedAt == nil {
+			res[task.Status]++
+		}
+	}
+	return res, nil
+}
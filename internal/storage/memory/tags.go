@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage"
+	"slices"
+	"strings"
+	"time"
+)
+
+func (s *Storage) CreateTag(ctx context.Context, uid uint64, name string) (*models.Tag, error) {
+	const op = "storage.memory.CreateTag"
+	if tooLong(name, maxTagLen) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInputTooLong)
+	}
+	defer s.lock(ctx)()
+	if _, ok := s.findTag(uid, name); ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrTagExists)
+	}
+	return s.createTag(uid, name), nil
+}
+
+func (s *Storage) ListTags(ctx context.Context, uid uint64) ([]*models.Tag, error) {
+	defer s.rlock(ctx)()
+	var tags []*models.Tag
+	for _, tag := range s.tags {
+		if tag.UserId == uid {
+			copied := *tag
+			tags = append(tags, &copied)
+		}
+	}
+	slices.SortFunc(tags, func(a, b *models.Tag) int { return strings.Compare(a.Name, b.Name) })
+	return tags, nil
+}
+
+func (s *Storage) RenameTag(ctx context.Context, id uint64, uid uint64, name string) (*models.Tag, error) {
+	const op = "storage.memory.RenameTag"
+	if tooLong(name, maxTagLen) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInputTooLong)
+	}
+	defer s.lock(ctx)()
+	tag, ok := s.tags[id]
+	if !ok || tag.UserId != uid {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrTagNotFound)
+	}
+	if other, ok := s.findTag(uid, name); ok && other.Id != id {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrTagExists)
+	}
+	for _, task := range s.tasks {
+		if task.UserId == uid {
+			if i := slices.Index(task.Tags, tag.Name); i >= 0 {
+				task.Tags[i] = name
+				slices.Sort(task.Tags)
+			}
+		}
+	}
+	tag.Name = name
+	copied := *tag
+	return &copied, nil
+}
+
+// DeleteTag removes a tag and detaches it from every task.
+func (s *Storage) DeleteTag(ctx context.Context, id uint64, uid uint64) error {
+	const op = "storage.memory.DeleteTag"
+	defer s.lock(ctx)()
+	tag, ok := s.tags[id]
+	if !ok || tag.UserId != uid {
+		return fmt.Errorf("%s: %w", op, storage.ErrTagNotFound)
+	}
+	for _, task := range s.tasks {
+		if task.UserId == uid {
+			task.Tags = slices.DeleteFunc(task.Tags, func(name string) bool { return name == tag.Name })
+		}
+	}
+	delete(s.tags, id)
+	return nil
+}
+
+// AddTaskTags attaches the named tags to a task, creating the tags that
+// the user does not have yet.
+func (s *Storage) AddTaskTags(ctx context.Context, id uint64, uid uint64, names []string) error {
+	const op = "storage.memory.AddTaskTags"
+	for _, name := range names {
+		if tooLong(name, maxTagLen) {
+			return fmt.Errorf("%s: %w", op, storage.ErrInputTooLong)
+		}
+	}
+	defer s.lock(ctx)()
+	task, ok := s.find(id, uid)
+	for _, name := range names {
+		if _, exists := s.findTag(uid, name); !exists {
+			s.createTag(uid, name)
+		}
+		if ok && !slices.Contains(task.Tags, name) {
+			task.Tags = append(task.Tags, name)
+		}
+	}
+	if ok {
+		slices.Sort(task.Tags)
+	}
+	return nil
+}
+
+func (s *Storage) RemoveTaskTags(ctx context.Context, id uint64, uid uint64, names []string) error {
+	defer s.lock(ctx)()
+	if task, ok := s.find(id, uid); ok {
+		task.Tags = slices.DeleteFunc(task.Tags, func(name string) bool { return slices.Contains(names, name) })
+	}
+	return nil
+}
+
+func (s *Storage) findTag(uid uint64, name string) (*models.Tag, bool) {
+	for _, tag := range s.tags {
+		if tag.UserId == uid && tag.Name == name {
+			return tag, true
+		}
+	}
+	return nil, false
+}
+
+func (s *Storage) createTag(uid uint64, name string) *models.Tag {
+	s.lastTagID++
+	tag := &models.Tag{Id: s.lastTagID, UserId: uid, Name: name, CreatedAt: time.Now()}
+	s.tags[tag.Id] = tag
+	copied := *tag
+	return &copied
+}
@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"github.com/Citadelas/task/internal/domain/models"
+	"maps"
+)
+
+type txKey struct{}
+
+// WithinTx runs fn while holding the storage lock, so the calls it makes
+// with the passed context see and apply changes atomically. If fn fails
+// every change it made is rolled back.
+//
+// Rollback restores a copy of every task, tag, dependency and series taken
+// before fn runs, so each outermost transaction costs time and memory in
+// proportion to everything stored, however little it writes. Writes made
+// outside WithinTx take no copy. That is fine for the development and test
+// data sets this storage is meant for, which is why the config refuses it
+// in prod; large data sets belong in SQLite or PostgreSQL.
+func (s *Storage) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if inTx(ctx) {
+		return fn(ctx)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks := make(map[uint64]*models.Task, len(s.tasks))
+	for id, task := range s.tasks {
+		tasks[id] = copyTask(task)
+	}
+	tags := make(map[uint64]*models.Tag, len(s.tags))
+	for id, tag := range s.tags {
+		copied := *tag
+		tags[id] = &copied
+	}
+	dependencies := maps.Clone(s.dependencies)
+	series := make(map[uint64]*models.Series, len(s.series))
+	for id, item := range s.series {
+		copied := *item
+		series[id] = &copied
+	}
+	lastSeriesID := s.lastSeriesID
+	lastID, history, lastHistoryID, lastTagID := s.lastID, len(s.history), s.lastHistoryID, s.lastTagID
+	if err := fn(context.WithValue(ctx, txKey{}, true)); err != nil {
+		s.tasks, s.lastID = tasks, lastID
+		s.tags, s.lastTagID = tags, lastTagID
+		s.dependencies = dependencies
+		s.series, s.lastSeriesID = series, lastSeriesID
+		s.history, s.lastHistoryID = s.history[:history], lastHistoryID
+		return err
+	}
+	return nil
+}
+
+func inTx(ctx context.Context) bool {
+	locked, _ := ctx.Value(txKey{}).(bool)
+	return locked
+}
+
+// lock takes the write lock unless ctx already runs inside WithinTx, and
+// returns the matching unlock.
+func (s *Storage) lock(ctx context.Context) func() {
+	if inTx(ctx) {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+func (s *Storage) rlock(ctx context.Context) func() {
+	if inTx(ctx) {
+		return func() {}
+	}
+	s.mu.RLock()
+	return s.mu.RUnlock
+}
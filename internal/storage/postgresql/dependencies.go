@@ -0,0 +1,63 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage"
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+// AddDependency records that blockerId blocks id. Adding an existing
+// dependency is a no-op. The caller checks that both tasks belong to the
+// user and that the edge does not close a cycle.
+func (s *Storage) AddDependency(ctx context.Context, id uint64, blockerId uint64) error {
+	const op = "storage.postgresql.AddDependency"
+	_, err := s.conn(ctx).Exec(ctx, "INSERT INTO task_dependencies(task_id, blocker_id) VALUES ($1, $2) "+
+		"ON CONFLICT DO NOTHING", id, blockerId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Storage) RemoveDependency(ctx context.Context, id uint64, blockerId uint64, uid uint64) error {
+	const op = "storage.postgresql.RemoveDependency"
+	commandTag, err := s.conn(ctx).Exec(ctx, "DELETE FROM task_dependencies d USING tasks k "+
+		"WHERE k.id = d.task_id AND d.task_id = $1 AND d.blocker_id = $2 AND k.user_id = $3", id, blockerId, uid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrDependencyNotFound)
+	}
+	return nil
+}
+
+// ListBlockers returns the live tasks that block id.
+func (s *Storage) ListBlockers(ctx context.Context, id uint64, uid uint64) ([]*models.Task, error) {
+	const op = "storage.postgresql.ListBlockers"
+	var tasks []*models.Task
+	err := pgxscan.Select(ctx, s.conn(ctx), &tasks, "SELECT "+columns+" FROM tasks WHERE id IN "+
+		"(SELECT blocker_id FROM task_dependencies WHERE task_id = $1) "+
+		"AND user_id = $2 AND deleted_at IS NULL ORDER BY id", id, uid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return tasks, nil
+}
+
+// ListTransitiveBlockers returns the ids of every task that blocks id
+// directly or through other tasks, trashed ones included.
+func (s *Storage) ListTransitiveBlockers(ctx context.Context, id uint64) ([]uint64, error) {
+	const op = "storage.postgresql.ListTransitiveBlockers"
+	var ids []uint64
+	err := pgxscan.Select(ctx, s.conn(ctx), &ids, "WITH RECURSIVE chain AS ("+
+		"SELECT blocker_id FROM task_dependencies WHERE task_id = $1 "+
+		"UNION SELECT d.blocker_id FROM task_dependencies d JOIN chain c ON d.task_id = c.blocker_id) "+
+		"SELECT blocker_id FROM chain", id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,42 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// EventsChannel is the LISTEN/NOTIFY channel that carries the user id of
+// every committed task change, so instances sharing the database can wake
+// their own watchers.
+const EventsChannel = "task_events"
+
+// ListenEvents calls listening once it is subscribed to EventsChannel and
+// then notify for every notification, until ctx ends or the connection
+// fails. Changes committed before listening are not reported.
+func (s *Storage) ListenEvents(ctx context.Context, listening func(), notify func(uid uint64)) error {
+	const op = "storage.postgresql.ListenEvents"
+	pooled, err := s.db.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	// The connection leaves the pool for good: it keeps listening, and a
+	// cancelled wait may leave it unusable.
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+	if _, err := conn.Exec(ctx, "LISTEN "+EventsChannel); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	listening()
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		uid, err := strconv.ParseUint(n.Payload, 10, 64)
+		if err != nil {
+			continue
+		}
+		notify(uid)
+	}
+}
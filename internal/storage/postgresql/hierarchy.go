@@ -0,0 +1,48 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+// MoveTask sets the parent of a task; a nil parentId makes it a top-level
+// task. The caller checks that the parent exists and is not a descendant.
+func (s *Storage) MoveTask(ctx context.Context, id uint64, uid uint64, version uint64,
+	parentId *uint64) (*models.Task, error) {
+	const op = "storage.postgresql.MoveTask"
+	var task models.Task
+	err := pgxscan.Get(ctx, s.conn(ctx), &task, "UPDATE tasks SET parent_id = $4, version = version + 1 "+
+		"WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)"+returning,
+		id, uid, version, parentId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, s.missingOrConflict(ctx, op, id, uid)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &task, nil
+}
+
+// ListSubtree returns a task followed by all of its live descendants in
+// creation order.
+func (s *Storage) ListSubtree(ctx context.Context, id uint64, uid uint64) ([]*models.Task, error) {
+	const op = "storage.postgresql.ListSubtree"
+	var tasks []*models.Task
+	err := pgxscan.Select(ctx, s.conn(ctx), &tasks, "WITH RECURSIVE subtree AS ("+
+		"SELECT id FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL "+
+		"UNION SELECT t.id FROM tasks t JOIN subtree s ON t.parent_id = s.id WHERE t.deleted_at IS NULL) "+
+		"SELECT "+columns+" FROM tasks WHERE id IN (SELECT id FROM subtree) ORDER BY id = $1 DESC, created_at, id",
+		id, uid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrTaskNotFound)
+	}
+	return tasks, nil
+}
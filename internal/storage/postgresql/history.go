@@ -0,0 +1,80 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"strconv"
+)
+
+const historyColumns = "id, task_id, user_id, actor_id, action, changes, created_at"
+
+// historyLockSpace is the first key of the advisory locks AddHistory takes
+// per user.
+const historyLockSpace = 1001
+
+// AddHistory also notifies EventsChannel with the user id, which listeners
+// receive once the transaction commits. It holds a per-user lock until
+// then, so a user's history ids are committed in order and watchers reading
+// after the last id they saw never skip an entry.
+func (s *Storage) AddHistory(ctx context.Context, entry models.HistoryEntry) (*models.HistoryEntry, error) {
+	const op = "storage.postgresql.AddHistory"
+	_, err := s.conn(ctx).Exec(ctx, "SELECT pg_advisory_xact_lock($1, $2::integer), pg_notify($3, $4)",
+		historyLockSpace, entry.UserId, EventsChannel, strconv.FormatUint(entry.UserId, 10))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	var res models.HistoryEntry
+	err = pgxscan.Get(ctx, s.conn(ctx), &res, "INSERT INTO task_history(task_id, user_id, actor_id, action, changes) "+
+		"VALUES ($1, $2, $3, $4, $5) RETURNING "+historyColumns,
+		entry.TaskId, entry.UserId, entry.ActorId, entry.Action, entry.Changes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &res, nil
+}
+
+func (s *Storage) ListHistory(ctx context.Context, id uint64, uid uint64,
+	pageSize int, pageToken string) (*models.HistoryPage, error) {
+	const op = "storage.postgresql.ListHistory"
+	var after uint64
+	if pageToken != "" {
+		cursor, err := storage.DecodeCursor(pageToken, storage.HistorySort, false)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		after = cursor.ID
+	}
+	var entries []*models.HistoryEntry
+	err := pgxscan.Select(ctx, s.conn(ctx), &entries, "SELECT "+historyColumns+" FROM task_history "+
+		"WHERE task_id = $1 AND user_id = $2 AND id > $3 ORDER BY id LIMIT $4",
+		id, uid, after, pageSize+1)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return storage.HistoryPageOf(entries, pageSize), nil
+}
+
+func (s *Storage) ListUserHistory(ctx context.Context, uid uint64, after uint64, limit int) ([]*models.HistoryEntry, error) {
+	const op = "storage.postgresql.ListUserHistory"
+	var entries []*models.HistoryEntry
+	err := pgxscan.Select(ctx, s.conn(ctx), &entries, "SELECT "+historyColumns+" FROM task_history "+
+		"WHERE user_id = $1 AND id > $2 ORDER BY id LIMIT $3",
+		uid, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return entries, nil
+}
+
+func (s *Storage) LastHistoryId(ctx context.Context, uid uint64) (uint64, error) {
+	const op = "storage.postgresql.LastHistoryId"
+	var id uint64
+	err := s.conn(ctx).QueryRow(ctx, "SELECT COALESCE(MAX(id), 0) FROM task_history WHERE user_id = $1", uid).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return id, nil
+}
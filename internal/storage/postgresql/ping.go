@@ -0,0 +1,15 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+)
+
+// Ping checks that the database can be reached.
+func (s *Storage) Ping(ctx context.Context) error {
+	const op = "storage.postgresql.Ping"
+	if err := s.db.Ping(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
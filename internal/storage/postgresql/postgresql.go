@@ -10,17 +10,34 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"strings"
+	"time"
 )
 
 type Storage struct {
 	db *pgxpool.Pool
 }
 
-const returning = "RETURNING id, user_id, title, description, priority, COALESCE(status, '') as status, created_at, due_date"
+const columns = "id, user_id, title, description, priority, COALESCE(status, '') as status, " +
+	"created_at, due_date, version, deleted_at, parent_id, series_id, occurrence, " + tagsColumn
+
+const tagsColumn = "(SELECT COALESCE(json_agg(g.name ORDER BY g.name), '[]') FROM task_tags tt " +
+	"JOIN tags g ON g.id = tt.tag_id WHERE tt.task_id = tasks.id) AS tags"
+
+const returning = " RETURNING " + columns
+
+const dueDateKey = "COALESCE(due_date, '9999-12-31 23:59:59+00'::timestamptz)"
+
+var priorityRank = storage.PriorityRankExpr("priority")
 
 func New(storagePath string) (*Storage, error) {
 	const op = "storage.postgresql.New"
-	db, err := pgxpool.New(context.Background(), storagePath)
+	config, err := pgxpool.ParseConfig(storagePath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	config.ConnConfig.Tracer = queryTracer{}
+	db, err := pgxpool.NewWithConfig(context.Background(), config)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -28,11 +45,11 @@ func New(storagePath string) (*Storage, error) {
 }
 
 func (s *Storage) CreateTask(ctx context.Context, uid uint64, title, description string,
-	priority string) (*models.Task, error) {
+	priority models.Priority, dueDate *time.Time) (*models.Task, error) {
 	const op = "storage.postgresql.CreateTask"
 	var task models.Task
-	err := pgxscan.Get(ctx, s.db, &task, "INSERT INTO tasks(user_id, title, description, priority) "+
-		"VALUES ($1, $2, $3, $4)"+returning, uid, title, description, priority)
+	err := pgxscan.Get(ctx, s.conn(ctx), &task, "INSERT INTO tasks(user_id, title, description, priority, due_date) "+
+		"VALUES ($1, $2, $3, $4, $5)"+returning, uid, title, description, priority, dueDate)
 	if err != nil {
 		if lerr := checkTooLongField(op, err); lerr != nil {
 			return nil, lerr
@@ -44,11 +61,14 @@ func (s *Storage) CreateTask(ctx context.Context, uid uint64, title, description
 
 func (s *Storage) GetTask(ctx context.Context, id uint64, uid uint64) (*models.Task, error) {
 	const op = "storage.postgresql.GetTask"
+	query := "SELECT " + columns + " FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL"
+	// Inside a transaction the row is locked so that the caller's view of
+	// the task stays current until it commits.
+	if inTx(ctx) {
+		query += " FOR UPDATE"
+	}
 	var task models.Task
-	err := pgxscan.Get(ctx, s.db, &task, ""+
-		"SELECT id, user_id, title, description, priority, "+
-		"COALESCE(status, '') as status, created_at, "+
-		"due_date FROM tasks WHERE id = $1 AND user_id = $2", id, uid)
+	err := pgxscan.Get(ctx, s.conn(ctx), &task, query, id, uid)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, fmt.Errorf("%s: %w", op, storage.ErrTaskNotFound)
@@ -58,22 +78,37 @@ func (s *Storage) GetTask(ctx context.Context, id uint64, uid uint64) (*models.T
 	return &task, nil
 }
 
-func (s *Storage) UpdateTask(ctx context.Context, id uint64, uid uint64, title, description string,
-	priority string) (*models.Task, error) {
-	const op = "storage.postgresql.GetTask"
+func (s *Storage) UpdateTask(ctx context.Context, id uint64, uid uint64, version uint64,
+	update models.TaskUpdate) (*models.Task, error) {
+	const op = "storage.postgresql.UpdateTask"
+	args := []any{id, uid, version}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	var sets []string
+	for _, field := range update.Mask {
+		switch field {
+		case models.FieldTitle:
+			sets = append(sets, "title = "+arg(update.Title))
+		case models.FieldDescription:
+			sets = append(sets, "description = "+arg(update.Description))
+		case models.FieldPriority:
+			sets = append(sets, "priority = "+arg(update.Priority))
+		case models.FieldDueDate:
+			sets = append(sets, "due_date = "+arg(update.DueDate))
+		}
+	}
+	// An empty mask still bumps the version, e.g. when only tags change.
+	sets = append(sets, "version = version + 1")
+
 	var task models.Task
-	query := `
-        UPDATE tasks 
-        SET 
-            title = COALESCE(NULLIF($3, ''), title),
-            description = COALESCE(NULLIF($4, ''), description),
-            priority = COALESCE(NULLIF($5, ''), priority)
-        WHERE id = $1 AND user_id = $2
-    ` + returning
-	err := pgxscan.Get(ctx, s.db, &task, query, id, uid, title, description, priority)
+	query := "UPDATE tasks SET " + strings.Join(sets, ", ") +
+		" WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)" + returning
+	err := pgxscan.Get(ctx, s.conn(ctx), &task, query, args...)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("%s: %w", op, storage.ErrTaskNotFound)
+			return nil, s.missingOrConflict(ctx, op, id, uid)
 		}
 		if lerr := checkTooLongField(op, err); lerr != nil {
 			return nil, lerr
@@ -83,26 +118,137 @@ func (s *Storage) UpdateTask(ctx context.Context, id uint64, uid uint64, title,
 	return &task, nil
 }
 
-func (s *Storage) UpdateStatus(ctx context.Context, id uint64, uid uint64, status string) (*models.Task, error) {
+func (s *Storage) UpdateStatus(ctx context.Context, id uint64, uid uint64, version uint64,
+	status models.Status) (*models.Task, error) {
 	const op = "storage.postgresql.UpdateStatus"
 	var task models.Task
-	err := pgxscan.Get(ctx, s.db, &task, "UPDATE tasks SET status = $1 WHERE id = $2 AND user_id = $3 RETURNING *", status, id, uid)
+	err := pgxscan.Get(ctx, s.conn(ctx), &task, "UPDATE tasks SET status = $1, version = version + 1 "+
+		"WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL AND ($4 = 0 OR version = $4)"+returning, status, id, uid, version)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("%s: %w", op, storage.ErrTaskNotFound)
+			return nil, s.missingOrConflict(ctx, op, id, uid)
 		}
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	return &task, nil
 }
 
-func (s *Storage) DeleteTask(ctx context.Context, id uint64, uid uint64) error {
+// DeleteTask moves a task to the trash. Trashed tasks are invisible to
+// every other query until restored, and are removed for good by PurgeTask
+// or PurgeTrash.
+func (s *Storage) DeleteTask(ctx context.Context, id uint64, uid uint64, version uint64) error {
 	const op = "storage.postgresql.DeleteTask"
-	commandTag, err := s.db.Exec(ctx, "DELETE FROM tasks WHERE id = $1 AND user_id = $2", id, uid)
+	commandTag, err := s.conn(ctx).Exec(ctx, "UPDATE tasks SET deleted_at = now(), version = version + 1 "+
+		"WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)", id, uid, version)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return s.missingOrConflict(ctx, op, id, uid)
+	}
+	return nil
+}
+
+// missingOrConflict explains why a conditional write matched no rows:
+// either the task does not exist or its version has moved on.
+func (s *Storage) missingOrConflict(ctx context.Context, op string, id, uid uint64) error {
+	var exists bool
+	err := s.conn(ctx).QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM tasks "+
+		"WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)", id, uid).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if exists {
+		return fmt.Errorf("%s: %w", op, storage.ErrVersionMismatch)
+	}
+	return fmt.Errorf("%s: %w", op, storage.ErrTaskNotFound)
+}
+
+func (s *Storage) ListTasks(ctx context.Context, query models.ListTasksQuery) (*models.TaskPage, error) {
+	const op = "storage.postgresql.ListTasks"
+	sortExpr := sortExpression(query.SortBy)
+	cmp, dir := ">", "ASC"
+	if query.Desc {
+		cmp, dir = "<", "DESC"
+	}
+
+	conds := []string{"user_id = $1", "deleted_at IS NULL"}
+	if query.Trashed {
+		conds[1] = "deleted_at IS NOT NULL"
+	}
+	args := []any{query.UserId}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if len(query.Filter.Statuses) > 0 {
+		conds = append(conds, "status = ANY("+arg(names(query.Filter.Statuses))+")")
+	}
+	if len(query.Filter.Priorities) > 0 {
+		conds = append(conds, "priority = ANY("+arg(names(query.Filter.Priorities))+")")
+	}
+	if query.Filter.DueFrom != nil {
+		conds = append(conds, "due_date >= "+arg(*query.Filter.DueFrom))
+	}
+	if query.Filter.DueTo != nil {
+		conds = append(conds, "due_date < "+arg(*query.Filter.DueTo))
+	}
+	if len(query.Filter.Tags) > 0 {
+		tagged := "FROM task_tags tt JOIN tags g ON g.id = tt.tag_id " +
+			"WHERE tt.task_id = tasks.id AND g.name = ANY(" + arg(query.Filter.Tags) + ")"
+		if query.Filter.TagMatch == models.TagMatchAll {
+			conds = append(conds, "(SELECT count(DISTINCT g.name) "+tagged+") = "+arg(len(query.Filter.Tags)))
+		} else {
+			conds = append(conds, "EXISTS (SELECT 1 "+tagged+")")
+		}
+	}
+	if query.PageToken != "" {
+		cursor, err := storage.DecodeCursor(query.PageToken, query.SortBy, query.Desc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		var key any = cursor.Time
+		if query.SortBy == models.SortByPriority {
+			key = cursor.Rank
+		}
+		conds = append(conds, fmt.Sprintf("(%s, id) %s (%s, %s)", sortExpr, cmp, arg(key), arg(cursor.ID)))
+	}
+
+	sql := "SELECT " + columns + " FROM tasks WHERE " + strings.Join(conds, " AND ") +
+		fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %s", sortExpr, dir, dir, arg(query.PageSize+1))
+
+	var tasks []*models.Task
+	if err := pgxscan.Select(ctx, s.conn(ctx), &tasks, sql, args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	page := &models.TaskPage{Tasks: tasks}
+	if len(tasks) > query.PageSize {
+		page.Tasks = tasks[:query.PageSize]
+		last := page.Tasks[len(page.Tasks)-1]
+		page.NextPageToken = storage.EncodeCursor(storage.CursorAfter(last, query.SortBy, query.Desc))
+	}
+	return page, nil
+}
+
+func (s *Storage) RestoreTask(ctx context.Context, id uint64, uid uint64) (*models.Task, error) {
+	const op = "storage.postgresql.RestoreTask"
+	var task models.Task
+	err := pgxscan.Get(ctx, s.conn(ctx), &task, "UPDATE tasks SET deleted_at = NULL, version = version + 1 "+
+		"WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL"+returning, id, uid)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return fmt.Errorf("%s: %w", op, storage.ErrTaskNotFound)
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrTaskNotFound)
 		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &task, nil
+}
+
+func (s *Storage) PurgeTask(ctx context.Context, id uint64, uid uint64) error {
+	const op = "storage.postgresql.PurgeTask"
+	commandTag, err := s.conn(ctx).Exec(ctx, "DELETE FROM tasks WHERE id = $1 AND user_id = $2 "+
+		"AND deleted_at IS NOT NULL", id, uid)
+	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 	if commandTag.RowsAffected() == 0 {
@@ -111,6 +257,44 @@ func (s *Storage) DeleteTask(ctx context.Context, id uint64, uid uint64) error {
 	return nil
 }
 
+// PurgeTrash permanently removes every task trashed before the cutoff and
+// returns the removed tasks with only their id and owner set.
+func (s *Storage) PurgeTrash(ctx context.Context, before time.Time) ([]*models.Task, error) {
+	const op = "storage.postgresql.PurgeTrash"
+	var purged []*models.Task
+	err := pgxscan.Select(ctx, s.conn(ctx), &purged, "DELETE FROM tasks WHERE deleted_at < $1 "+
+		"RETURNING id, user_id", before)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return purged, nil
+}
+
+// names converts enum values to plain strings so that pgx encodes them as
+// a text array.
+func names[T ~string](values []T) []string {
+	res := make([]string, len(values))
+	for i, value := range values {
+		res[i] = string(value)
+	}
+	return res
+}
+
+// sortExpression returns the column expression backing a sort field.
+// Priority is ranked so that HIGH orders above MEDIUM above LOW.
+func sortExpression(sortBy models.TaskSortField) string {
+	switch sortBy {
+	case models.SortByDueDate:
+		return dueDateKey
+	case models.SortByPriority:
+		return priorityRank
+	case models.SortByDeletedAt:
+		return "deleted_at"
+	default:
+		return "created_at"
+	}
+}
+
 func checkTooLongField(op string, err error) error {
 	var pgErr *pgconn.PgError
 	if errors.As(err, &pgErr) && pgErr.Code == "22001" {
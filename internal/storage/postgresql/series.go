@@ -0,0 +1,90 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+const seriesColumns = "id, user_id, rule, starts_at, stopped, created_at"
+
+func (s *Storage) CreateSeries(ctx context.Context, uid uint64, rule string, startsAt time.Time) (*models.Series, error) {
+	const op = "storage.postgresql.CreateSeries"
+	var series models.Series
+	err := pgxscan.Get(ctx, s.conn(ctx), &series, "INSERT INTO task_series(user_id, rule, starts_at) "+
+		"VALUES ($1, $2, $3) RETURNING "+seriesColumns, uid, rule, startsAt)
+	if err != nil {
+		if lerr := checkTooLongField(op, err); lerr != nil {
+			return nil, lerr
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &series, nil
+}
+
+func (s *Storage) GetSeries(ctx context.Context, id uint64, uid uint64) (*models.Series, error) {
+	const op = "storage.postgresql.GetSeries"
+	var series models.Series
+	err := pgxscan.Get(ctx, s.conn(ctx), &series, "SELECT "+seriesColumns+" FROM task_series "+
+		"WHERE id = $1 AND user_id = $2", id, uid)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrSeriesNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &series, nil
+}
+
+func (s *Storage) UpdateSeries(ctx context.Context, id uint64, uid uint64, rule string,
+	stopped bool) (*models.Series, error) {
+	const op = "storage.postgresql.UpdateSeries"
+	var series models.Series
+	err := pgxscan.Get(ctx, s.conn(ctx), &series, "UPDATE task_series SET rule = $3, stopped = $4 "+
+		"WHERE id = $1 AND user_id = $2 RETURNING "+seriesColumns, id, uid, rule, stopped)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrSeriesNotFound)
+		}
+		if lerr := checkTooLongField(op, err); lerr != nil {
+			return nil, lerr
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &series, nil
+}
+
+// SetOccurrence makes a task the given occurrence of a series.
+func (s *Storage) SetOccurrence(ctx context.Context, id uint64, uid uint64, seriesId uint64,
+	occurrence int) (*models.Task, error) {
+	const op = "storage.postgresql.SetOccurrence"
+	var task models.Task
+	err := pgxscan.Get(ctx, s.conn(ctx), &task, "UPDATE tasks SET series_id = $3, occurrence = $4, "+
+		"version = version + 1 WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL"+returning,
+		id, uid, seriesId, occurrence)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrTaskNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &task, nil
+}
+
+// OccurrenceExists reports whether a series already has the given
+// occurrence, trashed or not.
+func (s *Storage) OccurrenceExists(ctx context.Context, seriesId uint64, occurrence int) (bool, error) {
+	const op = "storage.postgresql.OccurrenceExists"
+	var exists bool
+	err := s.conn(ctx).QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM tasks WHERE series_id = $1 AND occurrence = $2)",
+		seriesId, occurrence).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return exists, nil
+}
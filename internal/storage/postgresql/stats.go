@@ -0,0 +1,78 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CountTasksByStatus counts the tasks outside the trash, by status.
+func (s *Storage) CountTasksByStatus(ctx context.Context) (map[models.Status]int64, error) {
+	const op = "storage.postgresql.CountTasksByStatus"
+	var rows []struct {
+		Status models.Status
+		Count  int64
+	}
+	err := pgxscan.Select(ctx, s.conn(ctx), &rows, "SELECT COALESCE(status, '') AS status, count(*) AS count "+
+		"FROM tasks WHERE deleted_at IS NULL GROUP BY 1")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	res := make(map[models.Status]int64, len(rows))
+	for _, row := range rows {
+		res[row.Status] += row.Count
+	}
+	return res, nil
+}
+
+// PoolCollector exposes the connection pool statistics as Prometheus
+// metrics.
+func (s *Storage) PoolCollector() prometheus.Collector {
+	return &poolCollector{storage: s}
+}
+
+var (
+	poolAcquiredDesc = prometheus.NewDesc("task_db_pool_acquired_conns",
+		"Connections currently in use.", nil, nil)
+	poolIdleDesc = prometheus.NewDesc("task_db_pool_idle_conns",
+		"Idle connections in the pool.", nil, nil)
+	poolTotalDesc = prometheus.NewDesc("task_db_pool_total_conns",
+		"Connections in the pool, in use, idle or being opened.", nil, nil)
+	poolMaxDesc = prometheus.NewDesc("task_db_pool_max_conns",
+		"Maximum size of the pool.", nil, nil)
+	poolAcquiresDesc = prometheus.NewDesc("task_db_pool_acquires_total",
+		"Successful connection acquisitions.", nil, nil)
+	poolEmptyAcquiresDesc = prometheus.NewDesc("task_db_pool_empty_acquires_total",
+		"Acquisitions that had to wait because the pool was empty.", nil, nil)
+	poolWaitDesc = prometheus.NewDesc("task_db_pool_acquire_wait_seconds_total",
+		"Time spent waiting for a connection because the pool was empty.", nil, nil)
+)
+
+type poolCollector struct {
+	storage *Storage
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolAcquiredDesc
+	ch <- poolIdleDesc
+	ch <- poolTotalDesc
+	ch <- poolMaxDesc
+	ch <- poolAcquiresDesc
+	ch <- poolEmptyAcquiresDesc
+	ch <- poolWaitDesc
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.storage.db.Stat()
+	ch <- prometheus.MustNewConstMetric(poolAcquiredDesc, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(poolIdleDesc, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(poolTotalDesc, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(poolMaxDesc, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(poolAcquiresDesc, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolEmptyAcquiresDesc, prometheus.CounterValue,
+		float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolWaitDesc, prometheus.CounterValue,
+		stat.EmptyAcquireWaitTime().Seconds())
+}
@@ -0,0 +1,105 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const tagColumns = "id, user_id, name, created_at"
+
+func (s *Storage) CreateTag(ctx context.Context, uid uint64, name string) (*models.Tag, error) {
+	const op = "storage.postgresql.CreateTag"
+	var tag models.Tag
+	err := pgxscan.Get(ctx, s.conn(ctx), &tag, "INSERT INTO tags(user_id, name) VALUES ($1, $2) RETURNING "+tagColumns,
+		uid, name)
+	if err != nil {
+		return nil, tagError(op, err)
+	}
+	return &tag, nil
+}
+
+func (s *Storage) ListTags(ctx context.Context, uid uint64) ([]*models.Tag, error) {
+	const op = "storage.postgresql.ListTags"
+	var tags []*models.Tag
+	err := pgxscan.Select(ctx, s.conn(ctx), &tags, "SELECT "+tagColumns+" FROM tags WHERE user_id = $1 ORDER BY name", uid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return tags, nil
+}
+
+func (s *Storage) RenameTag(ctx context.Context, id uint64, uid uint64, name string) (*models.Tag, error) {
+	const op = "storage.postgresql.RenameTag"
+	var tag models.Tag
+	err := pgxscan.Get(ctx, s.conn(ctx), &tag, "UPDATE tags SET name = $3 WHERE id = $1 AND user_id = $2 RETURNING "+tagColumns,
+		id, uid, name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrTagNotFound)
+		}
+		return nil, tagError(op, err)
+	}
+	return &tag, nil
+}
+
+// DeleteTag removes a tag and detaches it from every task.
+func (s *Storage) DeleteTag(ctx context.Context, id uint64, uid uint64) error {
+	const op = "storage.postgresql.DeleteTag"
+	commandTag, err := s.conn(ctx).Exec(ctx, "DELETE FROM tags WHERE id = $1 AND user_id = $2", id, uid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTagNotFound)
+	}
+	return nil
+}
+
+// AddTaskTags attaches the named tags to a task, creating the tags that
+// the user does not have yet.
+func (s *Storage) AddTaskTags(ctx context.Context, id uint64, uid uint64, names []string) error {
+	const op = "storage.postgresql.AddTaskTags"
+	_, err := s.conn(ctx).Exec(ctx, "INSERT INTO tags(user_id, name) SELECT $1, unnest($2::text[]) "+
+		"ON CONFLICT (user_id, name) DO NOTHING", uid, names)
+	if err != nil {
+		if lerr := checkTooLongField(op, err); lerr != nil {
+			return lerr
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	_, err = s.conn(ctx).Exec(ctx, "INSERT INTO task_tags(task_id, tag_id) "+
+		"SELECT k.id, g.id FROM tasks k JOIN tags g ON g.user_id = k.user_id "+
+		"WHERE k.id = $1 AND k.user_id = $2 AND k.deleted_at IS NULL AND g.name = ANY($3) "+
+		"ON CONFLICT DO NOTHING", id, uid, names)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Storage) RemoveTaskTags(ctx context.Context, id uint64, uid uint64, names []string) error {
+	const op = "storage.postgresql.RemoveTaskTags"
+	_, err := s.conn(ctx).Exec(ctx, "DELETE FROM task_tags tt USING tags g "+
+		"WHERE tt.tag_id = g.id AND tt.task_id = $1 AND g.user_id = $2 AND g.name = ANY($3)", id, uid, names)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func tagError(op string, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return fmt.Errorf("%s: %w", op, storage.ErrTagExists)
+	}
+	if lerr := checkTooLongField(op, err); lerr != nil {
+		return lerr
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
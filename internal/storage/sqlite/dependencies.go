@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage"
+	"github.com/georgysavva/scany/v2/sqlscan"
+	"time"
+)
+
+// AddDependency records that blockerId blocks id, see the postgres storage.
+func (s *Storage) AddDependency(ctx context.Context, id uint64, blockerId uint64) error {
+	const op = "storage.sqlite.AddDependency"
+	_, err := s.conn(ctx).ExecContext(ctx, "INSERT OR IGNORE INTO task_dependencies(task_id, blocker_id, created_at) "+
+		"VALUES (?, ?, ?)", id, blockerId, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Storage) RemoveDependency(ctx context.Context, id uint64, blockerId uint64, uid uint64) error {
+	const op = "storage.sqlite.RemoveDependency"
+	res, err := s.conn(ctx).ExecContext(ctx, "DELETE FROM task_dependencies WHERE task_id = ? AND blocker_id = ? "+
+		"AND task_id IN (SELECT id FROM tasks WHERE user_id = ?)", id, blockerId, uid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrDependencyNotFound)
+	}
+	return nil
+}
+
+// ListBlockers returns the live tasks that block id.
+func (s *Storage) ListBlockers(ctx context.Context, id uint64, uid uint64) ([]*models.Task, error) {
+	const op = "storage.sqlite.ListBlockers"
+	var tasks []*models.Task
+	err := sqlscan.Select(ctx, s.conn(ctx), &tasks, "SELECT "+columns+" FROM tasks WHERE id IN "+
+		"(SELECT blocker_id FROM task_dependencies WHERE task_id = ?) "+
+		"AND user_id = ? AND deleted_at IS NULL ORDER BY id", id, uid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return tasks, nil
+}
+
+// ListTransitiveBlockers returns the ids of every task that blocks id
+// directly or through other tasks, trashed ones included.
+func (s *Storage) ListTransitiveBlockers(ctx context.Context, id uint64) ([]uint64, error) {
+	const op = "storage.sqlite.ListTransitiveBlockers"
+	var ids []uint64
+	err := sqlscan.Select(ctx, s.conn(ctx), &ids, "WITH RECURSIVE chain AS ("+
+		"SELECT blocker_id FROM task_dependencies WHERE task_id = ? "+
+		"UNION SELECT d.blocker_id FROM task_dependencies d JOIN chain c ON d.task_id = c.blocker_id) "+
+		"SELECT blocker_id FROM chain", id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return ids, nil
+}
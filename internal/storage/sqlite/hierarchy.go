@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage"
+	"github.com/georgysavva/scany/v2/sqlscan"
+)
+
+// MoveTask sets the parent of a task, see the postgres storage.
+func (s *Storage) MoveTask(ctx context.Context, id uint64, uid uint64, version uint64,
+	parentId *uint64) (*models.Task, error) {
+	const op = "storage.sqlite.MoveTask"
+	var task models.Task
+	err := sqlscan.Get(ctx, s.conn(ctx), &task, "UPDATE tasks SET parent_id = ?, version = version + 1 "+
+		"WHERE id = ? AND user_id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?) RETURNING "+columns,
+		parentId, id, uid, version, version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, s.missingOrConflict(ctx, op, id, uid)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &task, nil
+}
+
+// ListSubtree returns a task followed by all of its live descendants in
+// creation order.
+func (s *Storage) ListSubtree(ctx context.Context, id uint64, uid uint64) ([]*models.Task, error) {
+	const op = "storage.sqlite.ListSubtree"
+	var tasks []*models.Task
+	err := sqlscan.Select(ctx, s.conn(ctx), &tasks, "WITH RECURSIVE subtree AS ("+
+		"SELECT id FROM tasks WHERE id = ? AND user_id = ? AND deleted_at IS NULL "+
+		"UNION SELECT t.id FROM tasks t JOIN subtree s ON t.parent_id = s.id WHERE t.deleted_at IS NULL) "+
+		"SELECT "+columns+" FROM tasks WHERE id IN (SELECT id FROM subtree) ORDER BY id = ? DESC, created_at, id",
+		id, uid, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrTaskNotFound)
+	}
+	return tasks, nil
+}
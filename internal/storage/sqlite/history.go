@@ -0,0 +1,67 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage"
+	"github.com/georgysavva/scany/v2/sqlscan"
+	"time"
+)
+
+const historyColumns = "id, task_id, user_id, actor_id, action, changes, created_at"
+
+func (s *Storage) AddHistory(ctx context.Context, entry models.HistoryEntry) (*models.HistoryEntry, error) {
+	const op = "storage.sqlite.AddHistory"
+	var res models.HistoryEntry
+	err := sqlscan.Get(ctx, s.conn(ctx), &res, "INSERT INTO task_history(task_id, user_id, actor_id, action, changes, created_at) "+
+		"VALUES (?, ?, ?, ?, ?, ?) RETURNING "+historyColumns,
+		entry.TaskId, entry.UserId, entry.ActorId, entry.Action, entry.Changes, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &res, nil
+}
+
+func (s *Storage) ListHistory(ctx context.Context, id uint64, uid uint64,
+	pageSize int, pageToken string) (*models.HistoryPage, error) {
+	const op = "storage.sqlite.ListHistory"
+	var after uint64
+	if pageToken != "" {
+		cursor, err := storage.DecodeCursor(pageToken, storage.HistorySort, false)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		after = cursor.ID
+	}
+	var entries []*models.HistoryEntry
+	err := sqlscan.Select(ctx, s.conn(ctx), &entries, "SELECT "+historyColumns+" FROM task_history "+
+		"WHERE task_id = ? AND user_id = ? AND id > ? ORDER BY id LIMIT ?",
+		id, uid, after, pageSize+1)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return storage.HistoryPageOf(entries, pageSize), nil
+}
+
+func (s *Storage) ListUserHistory(ctx context.Context, uid uint64, after uint64, limit int) ([]*models.HistoryEntry, error) {
+	const op = "storage.sqlite.ListUserHistory"
+	var entries []*models.HistoryEntry
+	err := sqlscan.Select(ctx, s.conn(ctx), &entries, "SELECT "+historyColumns+" FROM task_history "+
+		"WHERE user_id = ? AND id > ? ORDER BY id LIMIT ?",
+		uid, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return entries, nil
+}
+
+func (s *Storage) LastHistoryId(ctx context.Context, uid uint64) (uint64, error) {
+	const op = "storage.sqlite.LastHistoryId"
+	var id uint64
+	err := s.conn(ctx).QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM task_history WHERE user_id = ?", uid).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return id, nil
+}
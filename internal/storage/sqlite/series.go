@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage"
+	"github.com/georgysavva/scany/v2/sqlscan"
+	"time"
+)
+
+const seriesColumns = "id, user_id, rule, starts_at, stopped, created_at"
+
+func (s *Storage) CreateSeries(ctx context.Context, uid uint64, rule string, startsAt time.Time) (*models.Series, error) {
+	const op = "storage.sqlite.CreateSeries"
+	var series models.Series
+	err := sqlscan.Get(ctx, s.conn(ctx), &series, "INSERT INTO task_series(user_id, rule, starts_at, created_at) "+
+		"VALUES (?, ?, ?, ?) RETURNING "+seriesColumns, uid, rule, startsAt.UTC(), time.Now().UTC())
+	if err != nil {
+		if lerr := checkTooLongField(op, err); lerr != nil {
+			return nil, lerr
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &series, nil
+}
+
+func (s *Storage) GetSeries(ctx context.Context, id uint64, uid uint64) (*models.Series, error) {
+	const op = "storage.sqlite.GetSeries"
+	var series models.Series
+	err := sqlscan.Get(ctx, s.conn(ctx), &series, "SELECT "+seriesColumns+" FROM task_series "+
+		"WHERE id = ? AND user_id = ?", id, uid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrSeriesNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &series, nil
+}
+
+func (s *Storage) UpdateSeries(ctx context.Context, id uint64, uid uint64, rule string,
+	stopped bool) (*models.Series, error) {
+	const op = "storage.sqlite.UpdateSeries"
+	var series models.Series
+	err := sqlscan.Get(ctx, s.conn(ctx), &series, "UPDATE task_series SET rule = ?, stopped = ? "+
+		"WHERE id = ? AND user_id = ? RETURNING "+seriesColumns, rule, stopped, id, uid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrSeriesNotFound)
+		}
+		if lerr := checkTooLongField(op, err); lerr != nil {
+			return nil, lerr
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &series, nil
+}
+
+// SetOccurrence makes a task the given occurrence of a series.
+func (s *Storage) SetOccurrence(ctx context.Context, id uint64, uid uint64, seriesId uint64,
+	occurrence int) (*models.Task, error) {
+	const op = "storage.sqlite.SetOccurrence"
+	var task models.Task
+	err := sqlscan.Get(ctx, s.conn(ctx), &task, "UPDATE tasks SET series_id = ?, occurrence = ?, "+
+		"version = version + 1 WHERE id = ? AND user_id = ? AND deleted_at IS NULL RETURNING "+columns,
+		seriesId, occurrence, id, uid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrTaskNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &task, nil
+}
+
+// OccurrenceExists reports whether a series already has the given
+// occurrence, trashed or not.
+func (s *Storage) OccurrenceExists(ctx context.Context, seriesId uint64, occurrence int) (bool, error) {
+	const op = "storage.sqlite.OccurrenceExists"
+	var exists bool
+	err := s.conn(ctx).QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM tasks WHERE series_id = ? AND occurrence = ?)",
+		seriesId, occurrence).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return exists, nil
+}
@@ -0,0 +1,354 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage"
+	"github.com/Citadelas/task/migrations"
+	"github.com/georgysavva/scany/v2/sqlscan"
+	"github.com/golang-migrate/migrate/v4"
+	migratesqlite "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+	"strings"
+	"time"
+)
+
+type Storage struct {
+	db *sql.DB
+}
+
+const columns = "id, user_id, title, description, priority, COALESCE(status, '') as status, " +
+	"created_at, due_date, version, deleted_at, parent_id, series_id, occurrence, " + tagsColumn
+
+const tagsColumn = "(SELECT json_group_array(name) FROM (SELECT g.name FROM task_tags tt " +
+	"JOIN tags g ON g.id = tt.tag_id WHERE tt.task_id = tasks.id ORDER BY g.name)) AS tags"
+
+// Times are stored as UTC text in the driver's "sqlite" format, which
+// sorts lexically in chronological order. dueDateKey must match that
+// format for storage.NoDueDate.
+const dueDateKey = "COALESCE(due_date, '9999-12-31 23:59:59+00:00')"
+
+var priorityRank = storage.PriorityRankExpr("priority")
+
+// New opens the database file at storagePath and applies the embedded
+// migrations.
+func New(storagePath string) (*Storage, error) {
+	const op = "storage.sqlite.New"
+	db, err := sql.Open("sqlite", dsn(storagePath))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	// SQLite allows a single writer; serializing connections avoids
+	// SQLITE_BUSY under concurrent requests.
+	db.SetMaxOpenConns(1)
+	if err := migrateUp(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &Storage{db: db}, nil
+}
+
+func dsn(storagePath string) string {
+	params := []string{"_time_format=sqlite", "_pragma=foreign_keys(1)", "_pragma=busy_timeout(5000)"}
+	sep := "?"
+	if strings.Contains(storagePath, "?") {
+		sep = "&"
+	}
+	return storagePath + sep + strings.Join(params, "&")
+}
+
+func migrateUp(db *sql.DB) error {
+	source, err := iofs.New(migrations.SQLite, "sqlite")
+	if err != nil {
+		return err
+	}
+	driver, err := migratesqlite.WithInstance(db, &migratesqlite.Config{})
+	if err != nil {
+		return err
+	}
+	m, err := migrate.NewWithInstance("iofs", source, "sqlite", driver)
+	if err != nil {
+		return err
+	}
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) CreateTask(ctx context.Context, uid uint64, title, description string,
+	priority models.Priority, dueDate *time.Time) (*models.Task, error) {
+	const op = "storage.sqlite.CreateTask"
+	var task models.Task
+	err := sqlscan.Get(ctx, s.conn(ctx), &task, "INSERT INTO tasks(user_id, title, description, priority, created_at, due_date) "+
+		"VALUES (?, ?, ?, ?, ?, ?) RETURNING "+columns,
+		uid, title, description, priority, time.Now().UTC(), utc(dueDate))
+	if err != nil {
+		if lerr := checkTooLongField(op, err); lerr != nil {
+			return nil, lerr
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &task, nil
+}
+
+func (s *Storage) GetTask(ctx context.Context, id uint64, uid uint64) (*models.Task, error) {
+	const op = "storage.sqlite.GetTask"
+	var task models.Task
+	err := sqlscan.Get(ctx, s.conn(ctx), &task, "SELECT "+columns+" FROM tasks WHERE id = ? AND user_id = ? AND deleted_at IS NULL", id, uid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrTaskNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &task, nil
+}
+
+func (s *Storage) UpdateTask(ctx context.Context, id uint64, uid uint64, version uint64,
+	update models.TaskUpdate) (*models.Task, error) {
+	const op = "storage.sqlite.UpdateTask"
+	var sets []string
+	var args []any
+	for _, field := range update.Mask {
+		switch field {
+		case models.FieldTitle:
+			sets, args = append(sets, "title = ?"), append(args, update.Title)
+		case models.FieldDescription:
+			sets, args = append(sets, "description = ?"), append(args, update.Description)
+		case models.FieldPriority:
+			sets, args = append(sets, "priority = ?"), append(args, update.Priority)
+		case models.FieldDueDate:
+			sets, args = append(sets, "due_date = ?"), append(args, utc(update.DueDate))
+		}
+	}
+	// An empty mask still bumps the version, e.g. when only tags change.
+	sets = append(sets, "version = version + 1")
+
+	var task models.Task
+	query := "UPDATE tasks SET " + strings.Join(sets, ", ") +
+		" WHERE id = ? AND user_id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?) RETURNING " + columns
+	err := sqlscan.Get(ctx, s.conn(ctx), &task, query, append(args, id, uid, version, version)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, s.missingOrConflict(ctx, op, id, uid)
+		}
+		if lerr := checkTooLongField(op, err); lerr != nil {
+			return nil, lerr
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &task, nil
+}
+
+func (s *Storage) UpdateStatus(ctx context.Context, id uint64, uid uint64, version uint64,
+	status models.Status) (*models.Task, error) {
+	const op = "storage.sqlite.UpdateStatus"
+	var task models.Task
+	err := sqlscan.Get(ctx, s.conn(ctx), &task, "UPDATE tasks SET status = ?, version = version + 1 "+
+		"WHERE id = ? AND user_id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?) RETURNING "+columns,
+		status, id, uid, version, version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, s.missingOrConflict(ctx, op, id, uid)
+		}
+		if lerr := checkTooLongField(op, err); lerr != nil {
+			return nil, lerr
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &task, nil
+}
+
+// DeleteTask moves a task to the trash, see the postgres storage.
+func (s *Storage) DeleteTask(ctx context.Context, id uint64, uid uint64, version uint64) error {
+	const op = "storage.sqlite.DeleteTask"
+	res, err := s.conn(ctx).ExecContext(ctx, "UPDATE tasks SET deleted_at = ?, version = version + 1 "+
+		"WHERE id = ? AND user_id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)",
+		time.Now().UTC(), id, uid, version, version)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return s.missingOrConflict(ctx, op, id, uid)
+	}
+	return nil
+}
+
+// missingOrConflict explains why a conditional write matched no rows:
+// either the task does not exist or its version has moved on.
+func (s *Storage) missingOrConflict(ctx context.Context, op string, id, uid uint64) error {
+	var exists bool
+	err := s.conn(ctx).QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM tasks "+
+		"WHERE id = ? AND user_id = ? AND deleted_at IS NULL)", id, uid).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if exists {
+		return fmt.Errorf("%s: %w", op, storage.ErrVersionMismatch)
+	}
+	return fmt.Errorf("%s: %w", op, storage.ErrTaskNotFound)
+}
+
+func (s *Storage) ListTasks(ctx context.Context, query models.ListTasksQuery) (*models.TaskPage, error) {
+	const op = "storage.sqlite.ListTasks"
+	sortExpr := sortExpression(query.SortBy)
+	cmp, dir := ">", "ASC"
+	if query.Desc {
+		cmp, dir = "<", "DESC"
+	}
+
+	conds := []string{"user_id = ?", "deleted_at IS NULL"}
+	if query.Trashed {
+		conds[1] = "deleted_at IS NOT NULL"
+	}
+	args := []any{query.UserId}
+	if len(query.Filter.Statuses) > 0 {
+		conds = append(conds, "status IN ("+placeholders(len(query.Filter.Statuses))+")")
+		for _, status := range query.Filter.Statuses {
+			args = append(args, status)
+		}
+	}
+	if len(query.Filter.Priorities) > 0 {
+		conds = append(conds, "priority IN ("+placeholders(len(query.Filter.Priorities))+")")
+		for _, priority := range query.Filter.Priorities {
+			args = append(args, priority)
+		}
+	}
+	if query.Filter.DueFrom != nil {
+		conds, args = append(conds, "due_date >= ?"), append(args, query.Filter.DueFrom.UTC())
+	}
+	if query.Filter.DueTo != nil {
+		conds, args = append(conds, "due_date < ?"), append(args, query.Filter.DueTo.UTC())
+	}
+	if len(query.Filter.Tags) > 0 {
+		tagged := "FROM task_tags tt JOIN tags g ON g.id = tt.tag_id " +
+			"WHERE tt.task_id = tasks.id AND g.name IN (" + placeholders(len(query.Filter.Tags)) + ")"
+		if query.Filter.TagMatch == models.TagMatchAll {
+			conds = append(conds, "(SELECT count(DISTINCT g.name) "+tagged+") = ?")
+		} else {
+			conds = append(conds, "EXISTS (SELECT 1 "+tagged+")")
+		}
+		for _, tag := range query.Filter.Tags {
+			args = append(args, tag)
+		}
+		if query.Filter.TagMatch == models.TagMatchAll {
+			args = append(args, len(query.Filter.Tags))
+		}
+	}
+	if query.PageToken != "" {
+		cursor, err := storage.DecodeCursor(query.PageToken, query.SortBy, query.Desc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		var key any = cursor.Time.UTC()
+		if query.SortBy == models.SortByPriority {
+			key = cursor.Rank
+		}
+		conds = append(conds, fmt.Sprintf("(%s, id) %s (?, ?)", sortExpr, cmp))
+		args = append(args, key, cursor.ID)
+	}
+
+	sql := "SELECT " + columns + " FROM tasks WHERE " + strings.Join(conds, " AND ") +
+		fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT ?", sortExpr, dir, dir)
+	args = append(args, query.PageSize+1)
+
+	var tasks []*models.Task
+	if err := sqlscan.Select(ctx, s.conn(ctx), &tasks, sql, args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	page := &models.TaskPage{Tasks: tasks}
+	if len(tasks) > query.PageSize {
+		page.Tasks = tasks[:query.PageSize]
+		last := page.Tasks[len(page.Tasks)-1]
+		page.NextPageToken = storage.EncodeCursor(storage.CursorAfter(last, query.SortBy, query.Desc))
+	}
+	return page, nil
+}
+
+func (s *Storage) RestoreTask(ctx context.Context, id uint64, uid uint64) (*models.Task, error) {
+	const op = "storage.sqlite.RestoreTask"
+	var task models.Task
+	err := sqlscan.Get(ctx, s.conn(ctx), &task, "UPDATE tasks SET deleted_at = NULL, version = version + 1 "+
+		"WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL RETURNING "+columns, id, uid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrTaskNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &task, nil
+}
+
+func (s *Storage) PurgeTask(ctx context.Context, id uint64, uid uint64) error {
+	const op = "storage.sqlite.PurgeTask"
+	res, err := s.conn(ctx).ExecContext(ctx, "DELETE FROM tasks WHERE id = ? AND user_id = ? "+
+		"AND deleted_at IS NOT NULL", id, uid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTaskNotFound)
+	}
+	return nil
+}
+
+// PurgeTrash removes trashed tasks for good, see the postgres storage.
+func (s *Storage) PurgeTrash(ctx context.Context, before time.Time) ([]*models.Task, error) {
+	const op = "storage.sqlite.PurgeTrash"
+	var purged []*models.Task
+	err := sqlscan.Select(ctx, s.conn(ctx), &purged, "DELETE FROM tasks WHERE deleted_at < ? "+
+		"RETURNING id, user_id", before.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return purged, nil
+}
+
+func sortExpression(sortBy models.TaskSortField) string {
+	switch sortBy {
+	case models.SortByDueDate:
+		return dueDateKey
+	case models.SortByPriority:
+		return priorityRank
+	case models.SortByDeletedAt:
+		return "deleted_at"
+	default:
+		return "created_at"
+	}
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	res := t.UTC()
+	return &res
+}
+
+// checkTooLongField maps the length CHECK constraints of the schema to
+// storage.ErrInputTooLong.
+func checkTooLongField(op string, err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_CHECK {
+		return fmt.Errorf("%s: %w", op, storage.ErrInputTooLong)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package sqlite
+
+import (
+	"context"
+	"github.com/Citadelas/task/internal/domain/models"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// Times are stored as text with trailing zeros of the fraction dropped, so
+// "12:00:00+00:00", "12:00:00.1+00:00" and "12:00:00.12+00:00" must still
+// sort, and compare against page tokens, in chronological order.
+func TestListTasksPagesSubSecondTimes(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(filepath.Join(t.TempDir(), "tasks.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2030, time.January, 1, 12, 0, 0, 0, time.UTC)
+	// Offsets are given in id order; the chronological order differs.
+	offsets := []time.Duration{
+		time.Second,
+		120 * time.Millisecond,
+		0,
+		time.Second + time.Nanosecond,
+		100 * time.Millisecond,
+		999999 * time.Microsecond,
+		120*time.Millisecond + 500*time.Microsecond,
+	}
+	const uid = 1
+	byTime := make(map[time.Duration]uint64)
+	for _, offset := range offsets {
+		at := base.Add(offset)
+		task, err := s.CreateTask(ctx, uid, "title", "", models.PriorityLow, &at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.db.ExecContext(ctx, "UPDATE tasks SET created_at = ? WHERE id = ?", at, task.Id); err != nil {
+			t.Fatal(err)
+		}
+		byTime[offset] = task.Id
+	}
+	sorted := slices.Clone(offsets)
+	slices.Sort(sorted)
+	var want []uint64
+	for _, offset := range sorted {
+		want = append(want, byTime[offset])
+	}
+
+	for _, sortBy := range []models.TaskSortField{models.SortByCreatedAt, models.SortByDueDate} {
+		for _, desc := range []bool{false, true} {
+			query := models.ListTasksQuery{UserId: uid, SortBy: sortBy, Desc: desc, PageSize: 2}
+			var got []uint64
+			for {
+				page, err := s.ListTasks(ctx, query)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, task := range page.Tasks {
+					got = append(got, task.Id)
+				}
+				if page.NextPageToken == "" {
+					break
+				}
+				query.PageToken = page.NextPageToken
+			}
+			expected := slices.Clone(want)
+			if desc {
+				slices.Reverse(expected)
+			}
+			if !slices.Equal(got, expected) {
+				t.Errorf("%s desc=%t: ids = %v, want %v", sortBy, desc, got, expected)
+			}
+		}
+	}
+}
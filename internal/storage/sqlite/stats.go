@@ -0,0 +1,27 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/georgysavva/scany/v2/sqlscan"
+)
+
+// CountTasksByStatus counts the tasks outside the trash, by status.
+func (s *Storage) CountTasksByStatus(ctx context.Context) (map[models.Status]int64, error) {
+	const op = "storage.sqlite.CountTasksByStatus"
+	var rows []struct {
+		Status models.Status
+		Count  int64
+	}
+	err := sqlscan.Select(ctx, s.conn(ctx), &rows, "SELECT COALESCE(status, '') AS status, count(*) AS count "+
+		"FROM tasks WHERE deleted_at IS NULL GROUP BY 1")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	res := make(map[models.Status]int64, len(rows))
+	for _, row := range rows {
+		res[row.Status] += row.Count
+	}
+	return res, nil
+}
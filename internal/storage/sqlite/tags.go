@@ -0,0 +1,122 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage"
+	"github.com/georgysavva/scany/v2/sqlscan"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+	"time"
+)
+
+const tagColumns = "id, user_id, name, created_at"
+
+func (s *Storage) CreateTag(ctx context.Context, uid uint64, name string) (*models.Tag, error) {
+	const op = "storage.sqlite.CreateTag"
+	var tag models.Tag
+	err := sqlscan.Get(ctx, s.conn(ctx), &tag,
+		"INSERT INTO tags(user_id, name, created_at) VALUES (?, ?, ?) RETURNING "+tagColumns,
+		uid, name, time.Now().UTC())
+	if err != nil {
+		return nil, tagError(op, err)
+	}
+	return &tag, nil
+}
+
+func (s *Storage) ListTags(ctx context.Context, uid uint64) ([]*models.Tag, error) {
+	const op = "storage.sqlite.ListTags"
+	var tags []*models.Tag
+	err := sqlscan.Select(ctx, s.conn(ctx), &tags, "SELECT "+tagColumns+" FROM tags WHERE user_id = ? ORDER BY name", uid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return tags, nil
+}
+
+func (s *Storage) RenameTag(ctx context.Context, id uint64, uid uint64, name string) (*models.Tag, error) {
+	const op = "storage.sqlite.RenameTag"
+	var tag models.Tag
+	err := sqlscan.Get(ctx, s.conn(ctx), &tag,
+		"UPDATE tags SET name = ? WHERE id = ? AND user_id = ? RETURNING "+tagColumns, name, id, uid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrTagNotFound)
+		}
+		return nil, tagError(op, err)
+	}
+	return &tag, nil
+}
+
+// DeleteTag removes a tag and detaches it from every task.
+func (s *Storage) DeleteTag(ctx context.Context, id uint64, uid uint64) error {
+	const op = "storage.sqlite.DeleteTag"
+	res, err := s.conn(ctx).ExecContext(ctx, "DELETE FROM tags WHERE id = ? AND user_id = ?", id, uid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTagNotFound)
+	}
+	return nil
+}
+
+// AddTaskTags attaches the named tags to a task, creating the tags that
+// the user does not have yet.
+func (s *Storage) AddTaskTags(ctx context.Context, id uint64, uid uint64, names []string) error {
+	const op = "storage.sqlite.AddTaskTags"
+	now := time.Now().UTC()
+	for _, name := range names {
+		_, err := s.conn(ctx).ExecContext(ctx, "INSERT INTO tags(user_id, name, created_at) VALUES (?, ?, ?) "+
+			"ON CONFLICT (user_id, name) DO NOTHING", uid, name, now)
+		if err != nil {
+			if lerr := checkTooLongField(op, err); lerr != nil {
+				return lerr
+			}
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	args := []any{id, uid}
+	for _, name := range names {
+		args = append(args, name)
+	}
+	_, err := s.conn(ctx).ExecContext(ctx, "INSERT OR IGNORE INTO task_tags(task_id, tag_id) "+
+		"SELECT k.id, g.id FROM tasks k JOIN tags g ON g.user_id = k.user_id "+
+		"WHERE k.id = ? AND k.user_id = ? AND k.deleted_at IS NULL AND g.name IN ("+placeholders(len(names))+")", args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Storage) RemoveTaskTags(ctx context.Context, id uint64, uid uint64, names []string) error {
+	const op = "storage.sqlite.RemoveTaskTags"
+	args := []any{id, uid}
+	for _, name := range names {
+		args = append(args, name)
+	}
+	_, err := s.conn(ctx).ExecContext(ctx, "DELETE FROM task_tags WHERE task_id = ? AND tag_id IN "+
+		"(SELECT id FROM tags WHERE user_id = ? AND name IN ("+placeholders(len(names))+"))", args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func tagError(op string, err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return fmt.Errorf("%s: %w", op, storage.ErrTagExists)
+	}
+	if lerr := checkTooLongField(op, err); lerr != nil {
+		return lerr
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
@@ -3,6 +3,12 @@ package storage
 import "errors"
 
 var (
-	ErrTaskNotFound = errors.New("task not found")
-	ErrInputTooLong = errors.New("input value(s) is(are) too long")
+	ErrTaskNotFound       = errors.New("task not found")
+	ErrInputTooLong       = errors.New("input value(s) is(are) too long")
+	ErrInvalidCursor      = errors.New("invalid page cursor")
+	ErrVersionMismatch    = errors.New("task version mismatch")
+	ErrTagNotFound        = errors.New("tag not found")
+	ErrTagExists          = errors.New("tag already exists")
+	ErrDependencyNotFound = errors.New("dependency not found")
+	ErrSeriesNotFound     = errors.New("series not found")
 )
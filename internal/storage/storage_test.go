@@ -0,0 +1,456 @@
+package storage_test
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"github.com/Citadelas/task/internal/domain/models"
+	"github.com/Citadelas/task/internal/storage"
+	"github.com/Citadelas/task/internal/storage/storagetest"
+	"slices"
+	"testing"
+	"time"
+)
+
+// taskStorage is the part of the storage contract every backend shares.
+type taskStorage interface {
+	CreateTask(ctx context.Context, uid uint64, title, description string,
+		priority models.Priority, dueDate *time.Time) (*models.Task, error)
+	GetTask(ctx context.Context, id, uid uint64) (*models.Task, error)
+	UpdateTask(ctx context.Context, id, uid, version uint64, update models.TaskUpdate) (*models.Task, error)
+	UpdateStatus(ctx context.Context, id, uid, version uint64, status models.Status) (*models.Task, error)
+	DeleteTask(ctx context.Context, id, uid, version uint64) error
+	ListTasks(ctx context.Context, query models.ListTasksQuery) (*models.TaskPage, error)
+	RestoreTask(ctx context.Context, id, uid uint64) (*models.Task, error)
+	PurgeTask(ctx context.Context, id, uid uint64) error
+	PurgeTrash(ctx context.Context, before time.Time) ([]*models.Task, error)
+	CreateTag(ctx context.Context, uid uint64, name string) (*models.Tag, error)
+	ListTags(ctx context.Context, uid uint64) ([]*models.Tag, error)
+	AddTaskTags(ctx context.Context, id, uid uint64, names []string) error
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// forEachBackend runs test against every backend, each with a user id no
+// other test uses so that a shared PostgreSQL database needs no cleanup.
+func forEachBackend(t *testing.T, test func(t *testing.T, s taskStorage, uid uint64)) {
+	for _, b := range storagetest.Backends[taskStorage](t) {
+		t.Run(b.Name, func(t *testing.T) {
+			test(t, b.Storage, storagetest.NewUserId())
+		})
+	}
+}
+
+func TestCRUD(t *testing.T) {
+	ctx := context.Background()
+	due := time.Date(2031, time.May, 4, 10, 0, 0, 0, time.UTC)
+	forEachBackend(t, func(t *testing.T, s taskStorage, uid uint64) {
+		created, err := s.CreateTask(ctx, uid, "title", "description", models.PriorityHigh, &due)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if created.Id == 0 || created.UserId != uid || created.Title != "title" ||
+			created.Description != "description" || created.Priority != models.PriorityHigh ||
+			created.Status != models.StatusTodo || created.Version != 1 || created.CreatedAt.IsZero() ||
+			created.DueDate == nil || !created.DueDate.Equal(due) {
+			t.Fatalf("created = %+v", created)
+		}
+
+		got, err := s.GetTask(ctx, created.Id, uid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Id != created.Id || got.Title != created.Title || !got.DueDate.Equal(due) {
+			t.Errorf("got = %+v, want %+v", got, created)
+		}
+
+		updated, err := s.UpdateTask(ctx, created.Id, uid, created.Version, models.TaskUpdate{
+			Mask:  []string{models.FieldTitle, models.FieldDueDate},
+			Title: "renamed",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if updated.Title != "renamed" || updated.DueDate != nil || updated.Description != "description" ||
+			updated.Version != created.Version+1 {
+			t.Errorf("updated = %+v", updated)
+		}
+
+		moved, err := s.UpdateStatus(ctx, created.Id, uid, updated.Version, models.StatusDone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if moved.Status != models.StatusDone || moved.Version != updated.Version+1 {
+			t.Errorf("moved = %+v", moved)
+		}
+
+		if err := s.DeleteTask(ctx, created.Id, uid, moved.Version); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.GetTask(ctx, created.Id, uid); !errors.Is(err, storage.ErrTaskNotFound) {
+			t.Errorf("get deleted task error = %v, want %v", err, storage.ErrTaskNotFound)
+		}
+		restored, err := s.RestoreTask(ctx, created.Id, uid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if restored.DeletedAt != nil || restored.Title != "renamed" {
+			t.Errorf("restored = %+v", restored)
+		}
+
+		if err := s.DeleteTask(ctx, created.Id, uid, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.PurgeTask(ctx, created.Id, uid); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.RestoreTask(ctx, created.Id, uid); !errors.Is(err, storage.ErrTaskNotFound) {
+			t.Errorf("restore purged task error = %v, want %v", err, storage.ErrTaskNotFound)
+		}
+	})
+}
+
+func TestPurgeTrash(t *testing.T) {
+	ctx := context.Background()
+	forEachBackend(t, func(t *testing.T, s taskStorage, uid uint64) {
+		var trashed, kept []uint64
+		for i := range 4 {
+			task, err := s.CreateTask(ctx, uid, "title", "", models.PriorityLow, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if i%2 == 1 {
+				kept = append(kept, task.Id)
+				continue
+			}
+			if err := s.DeleteTask(ctx, task.Id, uid, 0); err != nil {
+				t.Fatal(err)
+			}
+			trashed = append(trashed, task.Id)
+		}
+		early, err := s.PurgeTrash(ctx, time.Now().Add(-time.Hour))
+		if err != nil || ownedBy(early, uid) != nil {
+			t.Errorf("purge before the deletions = %v, %v", ownedBy(early, uid), err)
+		}
+		purged, err := s.PurgeTrash(ctx, time.Now().Add(time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ownedBy(purged, uid)
+		slices.Sort(got)
+		if !slices.Equal(got, trashed) {
+			t.Errorf("purged = %v, want %v", got, trashed)
+		}
+		for _, id := range trashed {
+			if _, err := s.RestoreTask(ctx, id, uid); !errors.Is(err, storage.ErrTaskNotFound) {
+				t.Errorf("restore purged task %d: error = %v", id, err)
+			}
+		}
+		for _, id := range kept {
+			if _, err := s.GetTask(ctx, id, uid); err != nil {
+				t.Errorf("get kept task %d: %v", id, err)
+			}
+		}
+	})
+}
+
+// ownedBy lists the ids of the tasks owned by uid.
+func ownedBy(tasks []*models.Task, uid uint64) []uint64 {
+	var ids []uint64
+	for _, task := range tasks {
+		if task.UserId == uid {
+			ids = append(ids, task.Id)
+		}
+	}
+	return ids
+}
+
+func TestTaskNotFound(t *testing.T) {
+	ctx := context.Background()
+	forEachBackend(t, func(t *testing.T, s taskStorage, uid uint64) {
+		task, err := s.CreateTask(ctx, uid, "title", "", models.PriorityLow, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		const missing = storagetest.MissingId
+		other := uid + 1_000_000
+		calls := map[string]func() error{
+			"get missing": func() error {
+				_, err := s.GetTask(ctx, missing, uid)
+				return err
+			},
+			"get other user's": func() error {
+				_, err := s.GetTask(ctx, task.Id, other)
+				return err
+			},
+			"update missing": func() error {
+				_, err := s.UpdateTask(ctx, missing, uid, 0, models.TaskUpdate{
+					Mask: []string{models.FieldTitle}, Title: "x"})
+				return err
+			},
+			"update other user's": func() error {
+				_, err := s.UpdateTask(ctx, task.Id, other, 1, models.TaskUpdate{
+					Mask: []string{models.FieldTitle}, Title: "x"})
+				return err
+			},
+			"update status missing": func() error {
+				_, err := s.UpdateStatus(ctx, missing, uid, 0, models.StatusDone)
+				return err
+			},
+			"delete missing": func() error {
+				return s.DeleteTask(ctx, missing, uid, 0)
+			},
+			"delete other user's": func() error {
+				return s.DeleteTask(ctx, task.Id, other, 1)
+			},
+			"restore live task": func() error {
+				_, err := s.RestoreTask(ctx, task.Id, uid)
+				return err
+			},
+			"purge live task": func() error {
+				return s.PurgeTask(ctx, task.Id, uid)
+			},
+		}
+		for name, call := range calls {
+			if err := call(); !errors.Is(err, storage.ErrTaskNotFound) {
+				t.Errorf("%s: error = %v, want %v", name, err, storage.ErrTaskNotFound)
+			}
+		}
+	})
+}
+
+func TestVersionConflict(t *testing.T) {
+	ctx := context.Background()
+	forEachBackend(t, func(t *testing.T, s taskStorage, uid uint64) {
+		task, err := s.CreateTask(ctx, uid, "title", "", models.PriorityLow, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stale := task.Version
+		if _, err := s.UpdateTask(ctx, task.Id, uid, stale, models.TaskUpdate{
+			Mask: []string{models.FieldTitle}, Title: "first"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.UpdateTask(ctx, task.Id, uid, stale, models.TaskUpdate{
+			Mask: []string{models.FieldTitle}, Title: "second"}); !errors.Is(err, storage.ErrVersionMismatch) {
+			t.Errorf("update error = %v, want %v", err, storage.ErrVersionMismatch)
+		}
+		if _, err := s.UpdateStatus(ctx, task.Id, uid, stale, models.StatusDone); !errors.Is(err,
+			storage.ErrVersionMismatch) {
+			t.Errorf("update status error = %v, want %v", err, storage.ErrVersionMismatch)
+		}
+		if err := s.DeleteTask(ctx, task.Id, uid, stale); !errors.Is(err, storage.ErrVersionMismatch) {
+			t.Errorf("delete error = %v, want %v", err, storage.ErrVersionMismatch)
+		}
+		got, err := s.GetTask(ctx, task.Id, uid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Title != "first" || got.Status != models.StatusTodo || got.Version != stale+1 {
+			t.Errorf("task after conflicts = %+v", got)
+		}
+		// Version zero writes unconditionally.
+		if _, err := s.UpdateStatus(ctx, task.Id, uid, 0, models.StatusDone); err != nil {
+			t.Errorf("unconditional update status error = %v", err)
+		}
+	})
+}
+
+func TestWithinTxRollback(t *testing.T) {
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+	forEachBackend(t, func(t *testing.T, s taskStorage, uid uint64) {
+		kept, err := s.CreateTask(ctx, uid, "kept", "", models.PriorityLow, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var createdId uint64
+		err = s.WithinTx(ctx, func(ctx context.Context) error {
+			created, err := s.CreateTask(ctx, uid, "rolled back", "", models.PriorityLow, nil)
+			if err != nil {
+				return err
+			}
+			createdId = created.Id
+			if _, err := s.UpdateTask(ctx, kept.Id, uid, kept.Version, models.TaskUpdate{
+				Mask: []string{models.FieldTitle}, Title: "changed"}); err != nil {
+				return err
+			}
+			if err := s.AddTaskTags(ctx, kept.Id, uid, []string{"rolled-back"}); err != nil {
+				return err
+			}
+			// Nested calls join the outer transaction.
+			if err := s.WithinTx(ctx, func(ctx context.Context) error {
+				return s.DeleteTask(ctx, kept.Id, uid, 0)
+			}); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("WithinTx error = %v, want %v", err, errAbort)
+		}
+		if _, err := s.GetTask(ctx, createdId, uid); !errors.Is(err, storage.ErrTaskNotFound) {
+			t.Errorf("task created in rolled back tx: error = %v", err)
+		}
+		got, err := s.GetTask(ctx, kept.Id, uid)
+		if err != nil {
+			t.Fatalf("task deleted in rolled back tx: %v", err)
+		}
+		if got.Title != "kept" || got.Version != kept.Version || len(got.Tags) != 0 {
+			t.Errorf("task after rollback = %+v, want %+v", got, kept)
+		}
+		tags, err := s.ListTags(ctx, uid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tags) != 0 {
+			t.Errorf("tags after rollback = %v", tags)
+		}
+
+		err = s.WithinTx(ctx, func(ctx context.Context) error {
+			_, err := s.UpdateTask(ctx, kept.Id, uid, 0, models.TaskUpdate{
+				Mask: []string{models.FieldTitle}, Title: "committed"})
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := s.GetTask(ctx, kept.Id, uid); err != nil || got.Title != "committed" {
+			t.Errorf("task after commit = %+v, %v", got, err)
+		}
+	})
+}
+
+func TestListTasksPagination(t *testing.T) {
+	ctx := context.Background()
+	day := func(n int) *time.Time {
+		d := time.Date(2031, time.January, n, 0, 0, 0, 0, time.UTC)
+		return &d
+	}
+	// Ties in every sort key, so the id tie-breaker decides.
+	fixtures := []struct {
+		priority models.Priority
+		due      *time.Time
+		status   models.Status
+	}{
+		{models.PriorityHigh, day(3), models.StatusTodo},
+		{models.PriorityLow, day(1), models.StatusDone},
+		{models.PriorityMedium, day(3), models.StatusInProgress},
+		{models.PriorityHigh, nil, models.StatusTodo},
+		{models.PriorityLow, day(2), models.StatusTodo},
+		{models.PriorityMedium, day(1), models.StatusDone},
+		{models.PriorityLow, nil, models.StatusInProgress},
+	}
+	dueKey := func(task *models.Task) time.Time {
+		if task.DueDate == nil {
+			return storage.NoDueDate
+		}
+		return *task.DueDate
+	}
+	orders := map[models.TaskSortField]func(a, b *models.Task) int{
+		models.SortByCreatedAt: func(a, b *models.Task) int { return a.CreatedAt.Compare(b.CreatedAt) },
+		models.SortByDueDate:   func(a, b *models.Task) int { return dueKey(a).Compare(dueKey(b)) },
+		models.SortByPriority:  func(a, b *models.Task) int { return cmp.Compare(a.Priority.Rank(), b.Priority.Rank()) },
+	}
+	filters := map[string]struct {
+		filter models.TaskFilter
+		keep   func(*models.Task) bool
+	}{
+		"none": {keep: func(*models.Task) bool { return true }},
+		"status": {
+			filter: models.TaskFilter{Statuses: []models.Status{models.StatusTodo, models.StatusDone}},
+			keep:   func(t *models.Task) bool { return t.Status != models.StatusInProgress },
+		},
+		"priority": {
+			filter: models.TaskFilter{Priorities: []models.Priority{models.PriorityLow}},
+			keep:   func(t *models.Task) bool { return t.Priority == models.PriorityLow },
+		},
+		"due range": {
+			filter: models.TaskFilter{DueFrom: day(2), DueTo: day(4)},
+			keep: func(t *models.Task) bool {
+				return t.DueDate != nil && !t.DueDate.Before(*day(2)) && t.DueDate.Before(*day(4))
+			},
+		},
+	}
+	forEachBackend(t, func(t *testing.T, s taskStorage, uid uint64) {
+		var all []*models.Task
+		for i, f := range fixtures {
+			task, err := s.CreateTask(ctx, uid, "task", "", f.priority, f.due)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if task.Status != models.StatusTodo {
+				t.Errorf("fixture %d: new task status = %q, want TODO", i, task.Status)
+			}
+			if task, err = s.UpdateStatus(ctx, task.Id, uid, 0, f.status); err != nil {
+				t.Fatalf("fixture %d: %v", i, err)
+			}
+			all = append(all, task)
+		}
+		for sortBy, order := range orders {
+			for _, desc := range []bool{false, true} {
+				for name, f := range filters {
+					var want []uint64
+					sorted := slices.Clone(all)
+					slices.SortFunc(sorted, func(a, b *models.Task) int {
+						if c := order(a, b); c != 0 {
+							return c
+						}
+						return cmp.Compare(a.Id, b.Id)
+					})
+					if desc {
+						slices.Reverse(sorted)
+					}
+					for _, task := range sorted {
+						if f.keep(task) {
+							want = append(want, task.Id)
+						}
+					}
+					got := listAll(t, s, models.ListTasksQuery{
+						UserId: uid, Filter: f.filter, SortBy: sortBy, Desc: desc, PageSize: 2})
+					if !slices.Equal(got, want) {
+						t.Errorf("sort %s desc %v filter %s: ids = %v, want %v", sortBy, desc, name, got, want)
+					}
+				}
+			}
+		}
+
+		_, err := s.ListTasks(ctx, models.ListTasksQuery{UserId: uid, SortBy: models.SortByCreatedAt,
+			PageSize: 2, PageToken: "not a token"})
+		if !errors.Is(err, storage.ErrInvalidCursor) {
+			t.Errorf("invalid token error = %v, want %v", err, storage.ErrInvalidCursor)
+		}
+		page, err := s.ListTasks(ctx, models.ListTasksQuery{UserId: uid, SortBy: models.SortByDueDate,
+			PageSize: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = s.ListTasks(ctx, models.ListTasksQuery{UserId: uid, SortBy: models.SortByPriority,
+			PageSize: 2, PageToken: page.NextPageToken})
+		if !errors.Is(err, storage.ErrInvalidCursor) {
+			t.Errorf("token of another sort order: error = %v, want %v", err, storage.ErrInvalidCursor)
+		}
+	})
+}
+
+// listAll follows next page tokens to the end and returns the task ids in
+// the order they were listed.
+func listAll(t *testing.T, s taskStorage, query models.ListTasksQuery) []uint64 {
+	t.Helper()
+	var ids []uint64
+	for {
+		page, err := s.ListTasks(context.Background(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Tasks) > query.PageSize {
+			t.Fatalf("page of %d tasks, want at most %d", len(page.Tasks), query.PageSize)
+		}
+		for _, task := range page.Tasks {
+			ids = append(ids, task.Id)
+		}
+		if page.NextPageToken == "" {
+			return ids
+		}
+		query.PageToken = page.NextPageToken
+	}
+}
@@ -0,0 +1,74 @@
+// Package storagetest opens the storage backends tests run against and
+// hands out ids that fit every one of them.
+package storagetest
+
+import (
+	"github.com/Citadelas/task/internal/storage/memory"
+	"github.com/Citadelas/task/internal/storage/postgresql"
+	"github.com/Citadelas/task/internal/storage/sqlite"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// PostgresDSNEnv names a migrated PostgreSQL database to run the tests
+// against as well; without it they use memory and SQLite only.
+const PostgresDSNEnv = "TASK_TEST_POSTGRES_DSN"
+
+// MissingId is a task id no backend hands out during a test run. It is the
+// largest value of the PostgreSQL integer columns, far beyond their
+// sequences.
+const MissingId = math.MaxInt32
+
+type Backend[S any] struct {
+	Name    string
+	Storage S
+}
+
+// Backends opens memory, SQLite and, when PostgresDSNEnv is set,
+// PostgreSQL storages. Every one of them must implement S.
+func Backends[S any](t testing.TB) []Backend[S] {
+	t.Helper()
+	storages := []Backend[any]{{Name: "memory", Storage: memory.New()}}
+	sqliteStorage, err := sqlite.New(filepath.Join(t.TempDir(), "tasks.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	storages = append(storages, Backend[any]{Name: "sqlite", Storage: sqliteStorage})
+	if dsn := os.Getenv(PostgresDSNEnv); dsn != "" {
+		pg, err := postgresql.New(dsn)
+		if err != nil {
+			t.Fatalf("open postgres: %v", err)
+		}
+		storages = append(storages, Backend[any]{Name: "postgres", Storage: pg})
+	}
+	res := make([]Backend[S], 0, len(storages))
+	for _, b := range storages {
+		s, ok := b.Storage.(S)
+		if !ok {
+			t.Fatalf("%s storage does not implement %T", b.Name, (*S)(nil))
+		}
+		res = append(res, Backend[S]{Name: b.Name, Storage: s})
+	}
+	return res
+}
+
+var (
+	seedUserIds sync.Once
+	lastUserId  atomic.Uint64
+)
+
+// NewUserId returns a user id no other test of the run uses, so tests
+// sharing a PostgreSQL database do not see each other's tasks. Ids start
+// at a random point below 1<<30, so runs against the same database rarely
+// meet and stay well inside the integer columns.
+func NewUserId() uint64 {
+	seedUserIds.Do(func() {
+		lastUserId.Store(uint64(rand.Int32N(1 << 30)))
+	})
+	return lastUserId.Add(1)
+}
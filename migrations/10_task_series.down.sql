@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS tasks_series_occurrence_idx;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS occurrence;
+ALTER TABLE tasks DROP COLUMN IF EXISTS series_id;
+
+DROP TABLE IF EXISTS task_series;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS task_series (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    rule VARCHAR(255) NOT NULL,
+    starts_at TIMESTAMPTZ NOT NULL,
+    stopped BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS series_id INTEGER REFERENCES task_series (id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS occurrence INTEGER NOT NULL DEFAULT 0;
+
+CREATE UNIQUE INDEX IF NOT EXISTS tasks_series_occurrence_idx ON tasks (series_id, occurrence)
+    WHERE series_id IS NOT NULL;
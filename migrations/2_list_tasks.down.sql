@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS tasks_user_priority_idx;
+DROP INDEX IF EXISTS tasks_user_due_idx;
+DROP INDEX IF EXISTS tasks_user_created_idx;
+
+-- Backfilled tasks keep their TODO status; it cannot be told apart from
+-- one set on purpose.
+ALTER TABLE tasks ALTER COLUMN status DROP DEFAULT;
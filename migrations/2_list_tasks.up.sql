@@ -0,0 +1,10 @@
+UPDATE tasks SET status = 'TODO' WHERE status IS NULL OR status = '';
+ALTER TABLE tasks ALTER COLUMN status SET DEFAULT 'TODO';
+
+CREATE INDEX IF NOT EXISTS tasks_user_created_idx ON tasks (user_id, created_at, id);
+CREATE INDEX IF NOT EXISTS tasks_user_due_idx ON tasks (user_id, due_date, id);
+CREATE INDEX IF NOT EXISTS tasks_user_priority_idx ON tasks (
+    user_id,
+    (CASE priority WHEN 'LOW' THEN 0 WHEN 'MEDIUM' THEN 1 WHEN 'HIGH' THEN 2 ELSE -1 END),
+    id
+);
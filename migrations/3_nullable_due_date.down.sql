@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS tasks_user_due_idx;
+CREATE INDEX IF NOT EXISTS tasks_user_due_idx ON tasks (user_id, due_date, id);
+
+UPDATE tasks SET due_date = created_at WHERE due_date IS NULL;
+ALTER TABLE tasks ALTER COLUMN due_date SET DEFAULT now();
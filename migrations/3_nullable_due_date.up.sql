@@ -0,0 +1,8 @@
+ALTER TABLE tasks ALTER COLUMN due_date DROP DEFAULT;
+
+DROP INDEX IF EXISTS tasks_user_due_idx;
+CREATE INDEX IF NOT EXISTS tasks_user_due_idx ON tasks (
+    user_id,
+    COALESCE(due_date, '9999-12-31 23:59:59+00'::timestamptz),
+    id
+);
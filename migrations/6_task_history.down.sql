@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS task_history_immutable ON task_history;
+DROP FUNCTION IF EXISTS task_history_immutable();
+DROP TABLE IF EXISTS task_history;
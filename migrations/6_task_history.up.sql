@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS task_history (
+    id BIGSERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    actor_id BIGINT NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    changes JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS task_history_task_idx ON task_history (user_id, task_id, id);
+
+CREATE OR REPLACE FUNCTION task_history_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'task_history is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER task_history_immutable
+    BEFORE UPDATE OR DELETE ON task_history
+    FOR EACH ROW EXECUTE FUNCTION task_history_immutable();
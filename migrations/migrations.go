@@ -0,0 +1,10 @@
+// Package migrations embeds the schema migrations that are applied by the
+// service itself rather than by cmd/migrator.
+package migrations
+
+import "embed"
+
+// SQLite holds the migrations of the embedded SQLite storage.
+//
+//go:embed sqlite/*.sql
+var SQLite embed.FS
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS tasks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    title TEXT NOT NULL CHECK (length(title) <= 255),
+    description TEXT,
+    priority TEXT CHECK (length(priority) <= 50),
+    status TEXT DEFAULT 'TODO' CHECK (length(status) <= 50),
+    created_at TIMESTAMP NOT NULL,
+    due_date TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS tasks_user_created_idx ON tasks (user_id, created_at, id);
+CREATE INDEX IF NOT EXISTS tasks_user_due_idx ON tasks (
+    user_id,
+    COALESCE(due_date, '9999-12-31 23:59:59+00:00'),
+    id
+);
+CREATE INDEX IF NOT EXISTS tasks_user_priority_idx ON tasks (
+    user_id,
+    (CASE priority WHEN 'LOW' THEN 0 WHEN 'MEDIUM' THEN 1 WHEN 'HIGH' THEN 2 ELSE -1 END),
+    id
+);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS tasks_trash_purge_idx;
+DROP INDEX IF EXISTS tasks_user_trash_idx;
+
+DELETE FROM tasks WHERE deleted_at IS NOT NULL;
+ALTER TABLE tasks DROP COLUMN deleted_at;
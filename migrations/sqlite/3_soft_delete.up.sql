@@ -0,0 +1,4 @@
+ALTER TABLE tasks ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS tasks_user_trash_idx ON tasks (user_id, deleted_at, id) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS tasks_trash_purge_idx ON tasks (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS task_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    actor_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    changes TEXT NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS task_history_task_idx ON task_history (user_id, task_id, id);
+
+CREATE TRIGGER IF NOT EXISTS task_history_no_update BEFORE UPDATE ON task_history
+BEGIN
+    SELECT RAISE(ABORT, 'task_history is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS task_history_no_delete BEFORE DELETE ON task_history
+BEGIN
+    SELECT RAISE(ABORT, 'task_history is append-only');
+END;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS tasks_series_occurrence_idx;
+
+ALTER TABLE tasks DROP COLUMN occurrence;
+ALTER TABLE tasks DROP COLUMN series_id;
+
+DROP TABLE IF EXISTS task_series;
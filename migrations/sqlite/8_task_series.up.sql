@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS task_series (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    rule TEXT NOT NULL CHECK (length(rule) <= 255),
+    starts_at TIMESTAMP NOT NULL,
+    stopped BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP NOT NULL
+);
+
+ALTER TABLE tasks ADD COLUMN series_id INTEGER REFERENCES task_series (id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN occurrence INTEGER NOT NULL DEFAULT 0;
+
+CREATE UNIQUE INDEX IF NOT EXISTS tasks_series_occurrence_idx ON tasks (series_id, occurrence)
+    WHERE series_id IS NOT NULL;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS task_history_user_idx;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS task_history_user_idx ON task_history (user_id, id);